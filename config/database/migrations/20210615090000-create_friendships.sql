@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS friendships(
+  id SERIAL NOT NULL,
+  user_id INTEGER NOT NULL,
+  friend_id INTEGER NOT NULL,
+  status TEXT NOT NULL,
+  --
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  --
+  CONSTRAINT friendships_pk_id PRIMARY KEY (id),
+  CONSTRAINT friendships_uq_user_id_friend_id UNIQUE (user_id, friend_id),
+  CONSTRAINT friendships_ck_not_self CHECK (user_id <> friend_id),
+  CONSTRAINT friendships_fk_user_id FOREIGN KEY (user_id) REFERENCES users (id),
+  CONSTRAINT friendships_fk_friend_id FOREIGN KEY (friend_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS friendships_idx_friend_id_status ON friendships (friend_id, status);
+-- +migrate Down
+DROP TABLE IF EXISTS friendships;
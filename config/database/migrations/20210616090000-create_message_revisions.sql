@@ -0,0 +1,16 @@
+-- +migrate Up
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ DEFAULT NULL;
+CREATE TABLE IF NOT EXISTS message_revisions(
+  id SERIAL NOT NULL,
+  message_id INTEGER NOT NULL,
+  content TEXT NOT NULL,
+  --
+  created_at TIMESTAMPTZ NOT NULL,
+  --
+  CONSTRAINT message_revisions_pk_id PRIMARY KEY (id),
+  CONSTRAINT message_revisions_fk_message_id FOREIGN KEY (message_id) REFERENCES messages (id)
+);
+CREATE INDEX IF NOT EXISTS message_revisions_idx_message_id ON message_revisions (message_id);
+-- +migrate Down
+DROP TABLE IF EXISTS message_revisions;
+ALTER TABLE messages DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS reactions(
+  id SERIAL NOT NULL,
+  message_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  emoji TEXT NOT NULL,
+  --
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  --
+  CONSTRAINT reactions_pk_id PRIMARY KEY (id),
+  CONSTRAINT reactions_uq_user_id_message_id_emoji UNIQUE (user_id, message_id, emoji),
+  CONSTRAINT reactions_fk_message_id FOREIGN KEY (message_id) REFERENCES messages (id),
+  CONSTRAINT reactions_fk_user_id FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS reactions_idx_message_id ON reactions (message_id);
+-- +migrate Down
+DROP TABLE IF EXISTS reactions;
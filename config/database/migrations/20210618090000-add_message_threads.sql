@@ -0,0 +1,12 @@
+-- +migrate Up
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id INTEGER DEFAULT NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS last_reply_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE messages ADD CONSTRAINT messages_fk_parent_id FOREIGN KEY (parent_id) REFERENCES messages (id);
+CREATE INDEX IF NOT EXISTS messages_idx_parent_id_created_at ON messages (parent_id, created_at);
+-- +migrate Down
+DROP INDEX IF EXISTS messages_idx_parent_id_created_at;
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_fk_parent_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS last_reply_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_count;
+ALTER TABLE messages DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,10 @@
+-- +migrate Up
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS last_read_message_id INTEGER DEFAULT NULL;
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS last_read_at TIMESTAMPTZ DEFAULT NULL;
+ALTER TABLE participants ADD CONSTRAINT participants_fk_last_read_message_id FOREIGN KEY (last_read_message_id) REFERENCES messages (id);
+CREATE INDEX IF NOT EXISTS messages_idx_conversation_id_id ON messages (conversation_id, id);
+-- +migrate Down
+DROP INDEX IF EXISTS messages_idx_conversation_id_id;
+ALTER TABLE participants DROP CONSTRAINT IF EXISTS participants_fk_last_read_message_id;
+ALTER TABLE participants DROP COLUMN IF EXISTS last_read_at;
+ALTER TABLE participants DROP COLUMN IF EXISTS last_read_message_id;
@@ -0,0 +1,27 @@
+-- +migrate Up
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_type_check;
+ALTER TABLE messages ADD CONSTRAINT messages_type_check
+  CHECK (type IN ('MESSAGE_TYPE_TEXT', 'MESSAGE_TYPE_IMAGE', 'MESSAGE_TYPE_FILE'));
+CREATE TABLE IF NOT EXISTS attachments(
+  id SERIAL NOT NULL,
+  message_id INTEGER NOT NULL,
+  uploader_id INTEGER NOT NULL,
+  file_name TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size BIGINT NOT NULL CHECK (size >= 0),
+  storage_key TEXT NOT NULL,
+  --
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  --
+  CONSTRAINT attachments_pk_id PRIMARY KEY (id),
+  CONSTRAINT attachments_uq_storage_key UNIQUE (storage_key),
+  CONSTRAINT attachments_fk_message_id FOREIGN KEY (message_id) REFERENCES messages (id),
+  CONSTRAINT attachments_fk_uploader_id FOREIGN KEY (uploader_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS attachments_idx_message_id ON attachments (message_id);
+-- +migrate Down
+DROP TABLE IF EXISTS attachments;
+UPDATE messages SET type = 'MESSAGE_TYPE_TEXT' WHERE type <> 'MESSAGE_TYPE_TEXT';
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_type_check;
+ALTER TABLE messages ADD CONSTRAINT messages_type_check
+  CHECK (type IN ('MESSAGE_TYPE_TEXT'));
@@ -0,0 +1,16 @@
+-- +migrate Up
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS width INTEGER DEFAULT NULL;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS height INTEGER DEFAULT NULL;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS thumbnail_key TEXT DEFAULT NULL;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS thumbnail_content_type TEXT DEFAULT NULL;
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS processed_at TIMESTAMPTZ DEFAULT NULL;
+UPDATE attachments SET processed_at = created_at
+  WHERE content_type NOT IN ('image/gif', 'image/jpeg', 'image/png', 'image/webp');
+CREATE INDEX IF NOT EXISTS attachments_idx_created_at_unprocessed ON attachments (created_at) WHERE processed_at IS NULL;
+-- +migrate Down
+DROP INDEX IF EXISTS attachments_idx_created_at_unprocessed;
+ALTER TABLE attachments DROP COLUMN IF EXISTS processed_at;
+ALTER TABLE attachments DROP COLUMN IF EXISTS thumbnail_content_type;
+ALTER TABLE attachments DROP COLUMN IF EXISTS thumbnail_key;
+ALTER TABLE attachments DROP COLUMN IF EXISTS height;
+ALTER TABLE attachments DROP COLUMN IF EXISTS width;
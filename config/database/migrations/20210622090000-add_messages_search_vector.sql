@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+  GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+CREATE INDEX IF NOT EXISTS messages_idx_search_vector ON messages USING GIN (search_vector);
+-- +migrate Down
+DROP INDEX IF EXISTS messages_idx_search_vector;
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
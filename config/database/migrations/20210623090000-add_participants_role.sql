@@ -0,0 +1,24 @@
+-- +migrate Up
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'PARTICIPANT_ROLE_MEMBER';
+ALTER TABLE participants ADD CONSTRAINT participants_role_check
+  CHECK (role IN ('PARTICIPANT_ROLE_OWNER', 'PARTICIPANT_ROLE_ADMIN', 'PARTICIPANT_ROLE_MEMBER'));
+UPDATE participants AS p SET role = 'PARTICIPANT_ROLE_OWNER'
+  FROM conversations AS c
+  WHERE c.id = p.conversation_id AND c.type = 'CONVERSATION_TYPE_GROUP' AND c.creator_id = p.user_id;
+UPDATE participants SET role = 'PARTICIPANT_ROLE_OWNER'
+  WHERE id IN (
+    SELECT DISTINCT ON (p.conversation_id) p.id
+      FROM participants AS p
+      INNER JOIN conversations AS c ON c.id = p.conversation_id
+      WHERE c.type = 'CONVERSATION_TYPE_GROUP' AND NOT EXISTS (
+        SELECT 1 FROM participants AS o
+          WHERE o.conversation_id = p.conversation_id AND o.role = 'PARTICIPANT_ROLE_OWNER'
+      )
+      ORDER BY p.conversation_id, p.created_at, p.id
+  );
+CREATE UNIQUE INDEX IF NOT EXISTS participants_uidx_conversation_id_owner ON participants (conversation_id)
+  WHERE role = 'PARTICIPANT_ROLE_OWNER';
+-- +migrate Down
+DROP INDEX IF EXISTS participants_uidx_conversation_id_owner;
+ALTER TABLE participants DROP CONSTRAINT IF EXISTS participants_role_check;
+ALTER TABLE participants DROP COLUMN IF EXISTS role;
@@ -0,0 +1,17 @@
+-- +migrate Up
+DELETE FROM participants
+  WHERE id NOT IN (
+    SELECT DISTINCT ON (conversation_id, user_id) id
+      FROM participants
+      ORDER BY conversation_id, user_id,
+        CASE role
+          WHEN 'PARTICIPANT_ROLE_OWNER' THEN 0
+          WHEN 'PARTICIPANT_ROLE_ADMIN' THEN 1
+          ELSE 2
+        END,
+        created_at, id
+  );
+ALTER TABLE participants ADD CONSTRAINT participants_uq_conversation_id_user_id
+  UNIQUE (conversation_id, user_id);
+-- +migrate Down
+ALTER TABLE participants DROP CONSTRAINT IF EXISTS participants_uq_conversation_id_user_id;
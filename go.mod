@@ -5,6 +5,7 @@ go 1.16
 require (
 	firebase.google.com/go v3.13.0+incompatible
 	github.com/99designs/gqlgen v0.13.0
+	github.com/alicebob/miniredis/v2 v2.14.5
 	github.com/caarlos0/env/v6 v6.5.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/go-chi/chi v4.1.2+incompatible
@@ -12,17 +13,18 @@ require (
 	github.com/golangci/golangci-lint v1.40.1
 	github.com/google/uuid v1.2.0
 	github.com/google/wire v0.5.0
-	github.com/gorilla/websocket v1.4.2 // indirect
-	github.com/graph-gophers/dataloader v5.0.0+incompatible // indirect
+	github.com/gorilla/websocket v1.4.2
+	github.com/graph-gophers/dataloader v5.0.0+incompatible
 	github.com/jackc/pgx v3.6.2+incompatible
 	github.com/jackc/pgx/v4 v4.11.0
-	github.com/lib/pq v1.10.2 // indirect
+	github.com/lib/pq v1.10.2
 	github.com/onsi/gomega v1.12.0 // indirect
 	github.com/opentracing/opentracing-go v1.2.0 // indirect
 	github.com/rs/cors v1.7.0
 	github.com/rubenv/sql-migrate v0.0.0-20210408115534-a32ed26c37ea
 	github.com/segmentio/ksuid v1.0.3
 	github.com/vektah/gqlparser/v2 v2.1.0
+	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
 	golang.org/x/lint v0.0.0-20201208152925-83fdc39ff7b5
 	golang.org/x/tools v0.1.2-0.20210512205948-8287d5da45e4
 	google.golang.org/api v0.46.0
@@ -1,11 +1,18 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/caarlos0/env/v6"
 )
 
+// minSecretLength is the length under which a secret is refused outside of
+// development.
+const minSecretLength = 32
+
 type Config struct {
 	App struct {
 		Environment string `env:"APP_ENV"     envDefault:"development"`
@@ -16,6 +23,7 @@ type Config struct {
 	HTTP struct {
 		Port               int      `env:"PORT"                 envDefault:"8080"`
 		CORSAllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:"," envDefault:"[]"`
+		AdminAddr          string   `env:"ADMIN_ADDR"           envDefault:"127.0.0.1:6060"` // metrics listener, keep it off the public network, empty disables it
 	}
 	Redis struct {
 		Addr     string `env:"REDIS_URL"     envDefault:"0.0.0.0:6379"`
@@ -32,6 +40,30 @@ type Config struct {
 		MaxIdleConns    int           `env:"POSTGRES_MAX_IDLE_CONNS" envDefault:"0"`     // sets the maximum number of connections in the idle
 		MaxOpenConns    int           `env:"POSTGRES_MAX_OPEN_CONNS" envDefault:"5"`     // sets the maximum number of connections in the idle
 	}
+	EventBus struct {
+		Driver string `env:"EVENT_BUS_DRIVER" envDefault:"memory"` // memory for a single instance, redis to share events between instances
+	}
+	Subscription struct {
+		QueueSize      int    `env:"SUBSCRIPTION_QUEUE_SIZE"      envDefault:"32"`          // pending events kept per subscriber
+		OverflowPolicy string `env:"SUBSCRIPTION_OVERFLOW_POLICY" envDefault:"drop_oldest"` // drop_oldest, disconnect or coalesce
+	}
+	Cursor struct {
+		Secret string `env:"CURSOR_SECRET"` // key signing the pagination cursors handed to clients, required outside of development
+	}
+	Upload struct {
+		MaxFileSize  int64  `env:"UPLOAD_MAX_FILE_SIZE"  envDefault:"26214400"` // bytes, 25MiB
+		MaxImageSize int64  `env:"UPLOAD_MAX_IMAGE_SIZE" envDefault:"10485760"` // bytes, 10MiB
+		URLSecret    string `env:"ATTACHMENT_URL_SECRET"`                       // key signing the download URLs of the attachments, required outside of development
+	}
+	BlobStore struct {
+		Driver      string `env:"BLOB_STORE_DRIVER" envDefault:"local"` // local for the filesystem, s3 for an S3 compatible storage
+		Dir         string `env:"BLOB_STORE_DIR"    envDefault:"data/blobs"`
+		S3Endpoint  string `env:"S3_ENDPOINT"       envDefault:"http://0.0.0.0:9000"`
+		S3Region    string `env:"S3_REGION"         envDefault:"us-east-1"`
+		S3Bucket    string `env:"S3_BUCKET"         envDefault:"chat"`
+		S3AccessKey string `env:"S3_ACCESS_KEY"     envDefault:"chat"`
+		S3SecretKey string `env:"S3_SECRET_KEY"     envDefault:"chatchat"`
+	}
 	Firebase struct {
 		Credentials string `env:"FIREBASE_CREDENTIALS"     envDefault:"hoge"`
 	}
@@ -60,9 +92,57 @@ func NewConfigFromEnv() *Config {
 		panic(err)
 	}
 
+	if err := env.Parse(&c.EventBus); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&c.Subscription); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&c.Cursor); err != nil {
+		panic(err)
+	}
+
+	c.Cursor.Secret = requireSecret(c.App.Environment, "CURSOR_SECRET", c.Cursor.Secret)
+
+	if err := env.Parse(&c.Upload); err != nil {
+		panic(err)
+	}
+
+	c.Upload.URLSecret = requireSecret(c.App.Environment, "ATTACHMENT_URL_SECRET", c.Upload.URLSecret)
+
+	if err := env.Parse(&c.BlobStore); err != nil {
+		panic(err)
+	}
+
 	if err := env.Parse(&c.Firebase); err != nil {
 		panic(err)
 	}
 
 	return &c
 }
+
+// requireSecret refuses to start outside of development with a secret unset
+// or shorter than minSecretLength. In development an unset secret is
+// replaced by a random one, valid until the process stops.
+func requireSecret(environment, name, secret string) string {
+	if environment != "development" {
+		if len(secret) < minSecretLength {
+			panic(fmt.Errorf("%v must be set to at least %v characters", name, minSecretLength))
+		}
+
+		return secret
+	}
+
+	if secret != "" {
+		return secret
+	}
+
+	random := make([]byte, minSecretLength)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(random)
+}
@@ -14,13 +14,19 @@ import (
 	usecase "github.com/samthehai/chat/internal/domain/usecase"
 	usecaserepository "github.com/samthehai/chat/internal/domain/usecase/repository"
 	"github.com/samthehai/chat/internal/infrastructure/external/auth"
+	"github.com/samthehai/chat/internal/infrastructure/external/blobstore"
+	"github.com/samthehai/chat/internal/infrastructure/external/eventbus"
 	"github.com/samthehai/chat/internal/infrastructure/external/postgres"
 	"github.com/samthehai/chat/internal/infrastructure/external/redis"
+	"github.com/samthehai/chat/internal/infrastructure/external/unfurl"
 	"github.com/samthehai/chat/internal/infrastructure/repository"
+	"github.com/samthehai/chat/internal/infrastructure/repository/cursor"
 	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
 	"github.com/samthehai/chat/internal/infrastructure/repository/transactor"
 	"github.com/samthehai/chat/internal/interfaces/graph/loader"
 	loaderusecase "github.com/samthehai/chat/internal/interfaces/graph/loader/usecase"
+	"github.com/samthehai/chat/internal/interfaces/graph/model"
 	"github.com/samthehai/chat/internal/interfaces/graph/resolver"
 	resolverloader "github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
 	resolverusecase "github.com/samthehai/chat/internal/interfaces/graph/resolver/usecase"
@@ -33,6 +39,12 @@ var superSet = wire.NewSet(
 	provivePostgresConnectionConfig,
 	proviveFirebaseCredentials,
 	proviveServerOption,
+	proviveSubscriptionOption,
+	proviveEventBus,
+	proviveCursorCodec,
+	proviveAttachmentOption,
+	proviveAttachmentURLSigner,
+	proviveBlobStore,
 
 	wire.NewSet(
 		redis.NewRedisClient,
@@ -46,44 +58,65 @@ var superSet = wire.NewSet(
 		resolver.NewQueryResolver,
 		resolver.NewMessageResolver,
 		resolver.NewConversationResolver,
+		resolver.NewConversationEventResolver,
 		resolver.NewUserResolver,
+		resolver.NewFriendshipResolver,
+		resolver.NewFriendRequestResolver,
+		resolver.NewReactionEventResolver,
+		resolver.NewReadReceiptResolver,
+		resolver.NewTypingEventResolver,
+		resolver.NewPresenceResolver,
+		resolver.NewAttachmentResolver,
 		resolver.NewResolver,
 	),
 
 	wire.Bind(new(resolverusecase.MessageUsecase), new(*usecase.MessageUsecase)),
 	wire.Bind(new(resolverusecase.UserUsecase), new(*usecase.UserUsecase)),
+	wire.Bind(new(resolverusecase.FriendshipUsecase), new(*usecase.FriendshipUsecase)),
+	wire.Bind(new(resolverusecase.PresenceUsecase), new(*usecase.PresenceUsecase)),
+	wire.Bind(new(server.PresenceTracker), new(*usecase.PresenceUsecase)),
+	wire.Bind(new(server.AttachmentOpener), new(*usecase.MessageUsecase)),
 	wire.NewSet(
 		usecase.NewMessageUsecase,
 		usecase.NewUserUsecase,
+		usecase.NewFriendshipUsecase,
+		usecase.NewPresenceUsecase,
 	),
 
 	wire.Bind(new(usecaserepository.UserRepository), new(*repository.UserRepository)),
 	wire.Bind(new(usecaserepository.MessageRepository), new(*repository.MessageRepository)),
+	wire.Bind(new(usecaserepository.FriendshipRepository), new(*repository.FriendshipRepository)),
+	wire.Bind(new(usecaserepository.PresenceRepository), new(*repository.PresenceRepository)),
+	wire.Bind(new(usecaserepository.AttachmentRepository), new(*repository.AttachmentRepository)),
+	wire.Bind(new(usecaserepository.LinkPreviewRepository), new(*repository.LinkPreviewRepository)),
 	wire.Bind(new(usecaserepository.Transactor), new(*transactor.DBTransactor)),
 	wire.NewSet(
 		repository.NewMessageRepository,
 		repository.NewUserRepository,
+		repository.NewFriendshipRepository,
+		repository.NewPresenceRepository,
+		repository.NewAttachmentRepository,
+		repository.NewLinkPreviewRepository,
 		transactor.NewDBTransactor,
 	),
 
 	wire.Bind(new(external.Cacher), new(*redis.RedisClient)),
+	wire.Bind(new(external.LinkFetcher), new(*unfurl.Fetcher)),
 	wire.Bind(new(external.Authenticator), new(*middlewares.Authenticator)),
 	wire.Bind(new(external.Transactor), new(*transactor.DBTransactor)),
 	wire.NewSet(
 		middlewares.NewAuthenticator,
+		unfurl.NewFetcher,
 	),
 
-	wire.Bind(new(resolverloader.MessageLoader), new(*loader.MessageLoader)),
-	wire.Bind(new(resolverloader.ConversationLoader), new(*loader.ConversationLoader)),
-	wire.Bind(new(resolverloader.UserLoader), new(*loader.UserLoader)),
+	wire.Bind(new(resolverloader.Provider), new(*loader.Factory)),
 	wire.NewSet(
-		loader.NewMessageLoader,
-		loader.NewConversationLoader,
-		loader.NewUserLoader,
+		loader.NewFactory,
 	),
 
 	wire.Bind(new(loaderusecase.MessageUsecase), new(*usecase.MessageUsecase)),
 	wire.Bind(new(loaderusecase.UserUsecase), new(*usecase.UserUsecase)),
+	wire.Bind(new(loaderusecase.PresenceUsecase), new(*usecase.PresenceUsecase)),
 
 	wire.Bind(new(middlewares.AuthManager), new(*auth.FirebaseClient)),
 )
@@ -116,9 +149,55 @@ func proviveServerOption() server.ServerOption {
 		CORSAllowedOrigins: configObj.HTTP.CORSAllowedOrigins,
 		Environment:        configObj.App.Environment,
 		DebugUser:          configObj.Debug.User,
+		MaxUploadSize:      configObj.Upload.MaxFileSize,
+		AdminAddr:          configObj.HTTP.AdminAddr,
 	}
 }
 
+func proviveSubscriptionOption() subscription.Option {
+	return subscription.Option{
+		QueueSize:      configObj.Subscription.QueueSize,
+		OverflowPolicy: subscription.OverflowPolicy(configObj.Subscription.OverflowPolicy),
+	}
+}
+
+func proviveEventBus(redisClient *redis.RedisClient) (external.EventBus, func()) {
+	if configObj.EventBus.Driver == "redis" {
+		return redis.NewRedisEventBus(redisClient)
+	}
+
+	return eventbus.NewMemoryEventBus(), func() {}
+}
+
+func proviveCursorCodec() *cursor.Codec {
+	return cursor.NewCodec(configObj.Cursor.Secret)
+}
+
+func proviveAttachmentOption() usecase.AttachmentOption {
+	return usecase.AttachmentOption{
+		MaxFileSize:  configObj.Upload.MaxFileSize,
+		MaxImageSize: configObj.Upload.MaxImageSize,
+	}
+}
+
+func proviveAttachmentURLSigner() *model.AttachmentURLSigner {
+	return model.NewAttachmentURLSigner(configObj.Upload.URLSecret)
+}
+
+func proviveBlobStore() (external.BlobStore, error) {
+	if configObj.BlobStore.Driver == "s3" {
+		return blobstore.NewS3Store(blobstore.S3Option{
+			Endpoint:  configObj.BlobStore.S3Endpoint,
+			Region:    configObj.BlobStore.S3Region,
+			Bucket:    configObj.BlobStore.S3Bucket,
+			AccessKey: configObj.BlobStore.S3AccessKey,
+			SecretKey: configObj.BlobStore.S3SecretKey,
+		})
+	}
+
+	return blobstore.NewLocalStore(configObj.BlobStore.Dir)
+}
+
 func proviveFirebaseCredentials() string {
 	return configObj.Firebase.Credentials
 }
@@ -14,13 +14,19 @@ import (
 	"github.com/samthehai/chat/internal/domain/usecase"
 	repository2 "github.com/samthehai/chat/internal/domain/usecase/repository"
 	"github.com/samthehai/chat/internal/infrastructure/external/auth"
+	"github.com/samthehai/chat/internal/infrastructure/external/blobstore"
+	"github.com/samthehai/chat/internal/infrastructure/external/eventbus"
 	"github.com/samthehai/chat/internal/infrastructure/external/postgres"
 	"github.com/samthehai/chat/internal/infrastructure/external/redis"
+	"github.com/samthehai/chat/internal/infrastructure/external/unfurl"
 	"github.com/samthehai/chat/internal/infrastructure/repository"
+	"github.com/samthehai/chat/internal/infrastructure/repository/cursor"
 	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
 	"github.com/samthehai/chat/internal/infrastructure/repository/transactor"
 	"github.com/samthehai/chat/internal/interfaces/graph/loader"
 	usecase3 "github.com/samthehai/chat/internal/interfaces/graph/loader/usecase"
+	"github.com/samthehai/chat/internal/interfaces/graph/model"
 	"github.com/samthehai/chat/internal/interfaces/graph/resolver"
 	loader2 "github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
 	usecase2 "github.com/samthehai/chat/internal/interfaces/graph/resolver/usecase"
@@ -31,33 +37,58 @@ import (
 func InitializeServer() (server.Server, func(), error) {
 	redisClientOption := proviveRedisClientOption()
 	redisClient := redis.NewRedisClient(redisClientOption)
+	eventBus, cleanup := proviveEventBus(redisClient)
 	authenticator := middlewares.NewAuthenticator()
 	context := _wireContextValue
 	connectionConfig := provivePostgresConnectionConfig()
 	db := postgres.NewConnection(context, connectionConfig)
-	userRepository := repository.NewUserRepository(redisClient, authenticator, db)
+	option := proviveSubscriptionOption()
+	codec := proviveCursorCodec()
+	userRepository := repository.NewUserRepository(redisClient, eventBus, authenticator, db, option, codec)
 	dbTransactor := transactor.NewDBTransactor(db)
-	messageRepository := repository.NewMessageRepository(redisClient, dbTransactor, db)
-	messageUsecase := usecase.NewMessageUsecase(userRepository, messageRepository, dbTransactor)
+	messageRepository := repository.NewMessageRepository(redisClient, eventBus, dbTransactor, db, option, codec)
+	blobStore, err := proviveBlobStore()
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	attachmentRepository := repository.NewAttachmentRepository(blobStore, dbTransactor, db)
+	fetcher := unfurl.NewFetcher()
+	linkPreviewRepository := repository.NewLinkPreviewRepository(redisClient, fetcher)
+	attachmentOption := proviveAttachmentOption()
+	messageUsecase := usecase.NewMessageUsecase(userRepository, messageRepository, attachmentRepository, linkPreviewRepository, dbTransactor, attachmentOption)
 	userUsecase := usecase.NewUserUsecase(userRepository)
-	queryResolver := resolver.NewQueryResolver(messageUsecase, userUsecase)
-	mutationResolver := resolver.NewMutationResolver(messageUsecase, userUsecase)
-	subscriptionResolver := resolver.NewSubscriptionResolver(messageUsecase, userUsecase)
-	userLoader := loader.NewUserLoader(userUsecase)
-	conversationLoader := loader.NewConversationLoader(messageUsecase)
-	messageResolver := resolver.NewMessageResolver(userLoader, conversationLoader)
-	messageLoader := loader.NewMessageLoader(messageUsecase)
-	conversationResolver := resolver.NewConversationResolver(messageLoader, userLoader, conversationLoader)
-	userResolver := resolver.NewUserResolver(userLoader, conversationLoader)
-	resolverResolver := resolver.NewResolver(queryResolver, mutationResolver, subscriptionResolver, messageResolver, conversationResolver, userResolver)
+	friendshipRepository := repository.NewFriendshipRepository(dbTransactor, db)
+	friendshipUsecase := usecase.NewFriendshipUsecase(userRepository, friendshipRepository, dbTransactor)
+	queryResolver := resolver.NewQueryResolver(messageUsecase, userUsecase, friendshipUsecase)
+	presenceRepository := repository.NewPresenceRepository(redisClient, eventBus, db, option)
+	presenceUsecase := usecase.NewPresenceUsecase(userRepository, presenceRepository)
+	mutationResolver := resolver.NewMutationResolver(messageUsecase, userUsecase, friendshipUsecase, presenceUsecase)
+	subscriptionResolver := resolver.NewSubscriptionResolver(messageUsecase, userUsecase, presenceUsecase)
+	factory := loader.NewFactory(userUsecase, messageUsecase, presenceUsecase)
+	messageResolver := resolver.NewMessageResolver(factory)
+	conversationResolver := resolver.NewConversationResolver(factory)
+	conversationEventResolver := resolver.NewConversationEventResolver(factory)
+	userResolver := resolver.NewUserResolver(factory)
+	friendshipResolver := resolver.NewFriendshipResolver(factory)
+	friendRequestResolver := resolver.NewFriendRequestResolver(factory)
+	reactionEventResolver := resolver.NewReactionEventResolver(factory)
+	readReceiptResolver := resolver.NewReadReceiptResolver(factory)
+	typingEventResolver := resolver.NewTypingEventResolver(factory)
+	presenceResolver := resolver.NewPresenceResolver(factory)
+	attachmentURLSigner := proviveAttachmentURLSigner()
+	attachmentResolver := resolver.NewAttachmentResolver(userUsecase, attachmentURLSigner)
+	resolverResolver := resolver.NewResolver(queryResolver, mutationResolver, subscriptionResolver, messageResolver, conversationResolver, conversationEventResolver, userResolver, friendshipResolver, friendRequestResolver, reactionEventResolver, readReceiptResolver, typingEventResolver, presenceResolver, attachmentResolver)
 	string2 := proviveFirebaseCredentials()
 	firebaseClient, err := auth.NewFirebaseClient(context, string2)
 	if err != nil {
+		cleanup()
 		return nil, nil, err
 	}
 	serverOption := proviveServerOption()
-	serverServer, cleanup := server.NewServer(resolverResolver, firebaseClient, serverOption)
+	serverServer, cleanup2 := server.NewServer(resolverResolver, firebaseClient, factory, presenceUsecase, messageUsecase, attachmentURLSigner, serverOption)
 	return serverServer, func() {
+		cleanup2()
 		cleanup()
 	}, nil
 }
@@ -71,7 +102,13 @@ var (
 var superSet = wire.NewSet(wire.InterfaceValue(new(context.Context), context.Background()), proviveRedisClientOption,
 	provivePostgresConnectionConfig,
 	proviveFirebaseCredentials,
-	proviveServerOption, wire.NewSet(redis.NewRedisClient, postgres.NewConnection, auth.NewFirebaseClient, server.NewServer), wire.NewSet(resolver.NewSubscriptionResolver, resolver.NewMutationResolver, resolver.NewQueryResolver, resolver.NewMessageResolver, resolver.NewConversationResolver, resolver.NewUserResolver, resolver.NewResolver), wire.Bind(new(usecase2.MessageUsecase), new(*usecase.MessageUsecase)), wire.Bind(new(usecase2.UserUsecase), new(*usecase.UserUsecase)), wire.NewSet(usecase.NewMessageUsecase, usecase.NewUserUsecase), wire.Bind(new(repository2.UserRepository), new(*repository.UserRepository)), wire.Bind(new(repository2.MessageRepository), new(*repository.MessageRepository)), wire.Bind(new(repository2.Transactor), new(*transactor.DBTransactor)), wire.NewSet(repository.NewMessageRepository, repository.NewUserRepository, transactor.NewDBTransactor), wire.Bind(new(external.Cacher), new(*redis.RedisClient)), wire.Bind(new(external.Authenticator), new(*middlewares.Authenticator)), wire.Bind(new(external.Transactor), new(*transactor.DBTransactor)), wire.NewSet(middlewares.NewAuthenticator), wire.Bind(new(loader2.MessageLoader), new(*loader.MessageLoader)), wire.Bind(new(loader2.ConversationLoader), new(*loader.ConversationLoader)), wire.Bind(new(loader2.UserLoader), new(*loader.UserLoader)), wire.NewSet(loader.NewMessageLoader, loader.NewConversationLoader, loader.NewUserLoader), wire.Bind(new(usecase3.MessageUsecase), new(*usecase.MessageUsecase)), wire.Bind(new(usecase3.UserUsecase), new(*usecase.UserUsecase)), wire.Bind(new(middlewares.AuthManager), new(*auth.FirebaseClient)),
+	proviveServerOption,
+	proviveSubscriptionOption,
+	proviveEventBus,
+	proviveCursorCodec,
+	proviveAttachmentOption,
+	proviveAttachmentURLSigner,
+	proviveBlobStore, wire.NewSet(redis.NewRedisClient, postgres.NewConnection, auth.NewFirebaseClient, server.NewServer), wire.NewSet(resolver.NewSubscriptionResolver, resolver.NewMutationResolver, resolver.NewQueryResolver, resolver.NewMessageResolver, resolver.NewConversationResolver, resolver.NewConversationEventResolver, resolver.NewUserResolver, resolver.NewFriendshipResolver, resolver.NewFriendRequestResolver, resolver.NewReactionEventResolver, resolver.NewReadReceiptResolver, resolver.NewTypingEventResolver, resolver.NewPresenceResolver, resolver.NewAttachmentResolver, resolver.NewResolver), wire.Bind(new(usecase2.MessageUsecase), new(*usecase.MessageUsecase)), wire.Bind(new(usecase2.UserUsecase), new(*usecase.UserUsecase)), wire.Bind(new(usecase2.FriendshipUsecase), new(*usecase.FriendshipUsecase)), wire.Bind(new(usecase2.PresenceUsecase), new(*usecase.PresenceUsecase)), wire.Bind(new(server.PresenceTracker), new(*usecase.PresenceUsecase)), wire.Bind(new(server.AttachmentOpener), new(*usecase.MessageUsecase)), wire.NewSet(usecase.NewMessageUsecase, usecase.NewUserUsecase, usecase.NewFriendshipUsecase, usecase.NewPresenceUsecase), wire.Bind(new(repository2.UserRepository), new(*repository.UserRepository)), wire.Bind(new(repository2.MessageRepository), new(*repository.MessageRepository)), wire.Bind(new(repository2.FriendshipRepository), new(*repository.FriendshipRepository)), wire.Bind(new(repository2.PresenceRepository), new(*repository.PresenceRepository)), wire.Bind(new(repository2.AttachmentRepository), new(*repository.AttachmentRepository)), wire.Bind(new(repository2.LinkPreviewRepository), new(*repository.LinkPreviewRepository)), wire.Bind(new(repository2.Transactor), new(*transactor.DBTransactor)), wire.NewSet(repository.NewMessageRepository, repository.NewUserRepository, repository.NewFriendshipRepository, repository.NewPresenceRepository, repository.NewAttachmentRepository, repository.NewLinkPreviewRepository, transactor.NewDBTransactor), wire.Bind(new(external.Cacher), new(*redis.RedisClient)), wire.Bind(new(external.LinkFetcher), new(*unfurl.Fetcher)), wire.Bind(new(external.Authenticator), new(*middlewares.Authenticator)), wire.Bind(new(external.Transactor), new(*transactor.DBTransactor)), wire.NewSet(middlewares.NewAuthenticator, unfurl.NewFetcher), wire.Bind(new(loader2.Provider), new(*loader.Factory)), wire.NewSet(loader.NewFactory), wire.Bind(new(usecase3.MessageUsecase), new(*usecase.MessageUsecase)), wire.Bind(new(usecase3.UserUsecase), new(*usecase.UserUsecase)), wire.Bind(new(usecase3.PresenceUsecase), new(*usecase.PresenceUsecase)), wire.Bind(new(middlewares.AuthManager), new(*auth.FirebaseClient)),
 )
 
 var configObj = config.NewConfigFromEnv()
@@ -102,7 +139,53 @@ func proviveServerOption() server.ServerOption {
 		CORSAllowedOrigins: configObj.HTTP.CORSAllowedOrigins,
 		Environment:        configObj.App.Environment,
 		DebugUser:          configObj.Debug.User,
+		MaxUploadSize:      configObj.Upload.MaxFileSize,
+		AdminAddr:          configObj.HTTP.AdminAddr,
+	}
+}
+
+func proviveSubscriptionOption() subscription.Option {
+	return subscription.Option{
+		QueueSize:      configObj.Subscription.QueueSize,
+		OverflowPolicy: subscription.OverflowPolicy(configObj.Subscription.OverflowPolicy),
+	}
+}
+
+func proviveEventBus(redisClient *redis.RedisClient) (external.EventBus, func()) {
+	if configObj.EventBus.Driver == "redis" {
+		return redis.NewRedisEventBus(redisClient)
 	}
+
+	return eventbus.NewMemoryEventBus(), func() {}
+}
+
+func proviveCursorCodec() *cursor.Codec {
+	return cursor.NewCodec(configObj.Cursor.Secret)
+}
+
+func proviveAttachmentOption() usecase.AttachmentOption {
+	return usecase.AttachmentOption{
+		MaxFileSize:  configObj.Upload.MaxFileSize,
+		MaxImageSize: configObj.Upload.MaxImageSize,
+	}
+}
+
+func proviveAttachmentURLSigner() *model.AttachmentURLSigner {
+	return model.NewAttachmentURLSigner(configObj.Upload.URLSecret)
+}
+
+func proviveBlobStore() (external.BlobStore, error) {
+	if configObj.BlobStore.Driver == "s3" {
+		return blobstore.NewS3Store(blobstore.S3Option{
+			Endpoint:  configObj.BlobStore.S3Endpoint,
+			Region:    configObj.BlobStore.S3Region,
+			Bucket:    configObj.BlobStore.S3Bucket,
+			AccessKey: configObj.BlobStore.S3AccessKey,
+			SecretKey: configObj.BlobStore.S3SecretKey,
+		})
+	}
+
+	return blobstore.NewLocalStore(configObj.BlobStore.Dir)
 }
 
 func proviveFirebaseCredentials() string {
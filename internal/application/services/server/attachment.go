@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/samthehai/chat/internal/application/services/server/middlewares"
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+// AttachmentOpener gives the attachments to the users allowed to see them.
+type AttachmentOpener interface {
+	OpenAttachment(ctx context.Context, attachmentID entity.ID) (
+		*entity.Attachment, io.ReadCloser, error)
+	OpenThumbnail(ctx context.Context, attachmentID entity.ID) (
+		*entity.Attachment, io.ReadCloser, error)
+}
+
+// downloadAttachment streams the content of an attachment.
+func (s *server) downloadAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, content, ok := s.openAttachment(w, r, s.attachments.OpenAttachment)
+	if !ok {
+		return
+	}
+	defer content.Close()
+
+	// only images are shown inline, anything else could be rendered by the
+	// browser as a page of this origin
+	disposition := "attachment"
+	if attachment.IsImage() {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition,
+		map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("failed to send attachment: %v\n", err)
+	}
+}
+
+// downloadThumbnail streams the thumbnail of an image attachment.
+func (s *server) downloadThumbnail(w http.ResponseWriter, r *http.Request) {
+	attachment, content, ok := s.openAttachment(w, r, s.attachments.OpenThumbnail)
+	if !ok {
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", *attachment.ThumbnailContentType)
+	w.Header().Set("Content-Disposition", "inline")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("failed to send thumbnail: %v\n", err)
+	}
+}
+
+// openAttachment opens the attachment of the request as the viewer its URL
+// was signed for, on failure the error is written and ok is false.
+// Attachments the viewer may not see are reported missing so that their
+// existence is not told.
+func (s *server) openAttachment(
+	w http.ResponseWriter,
+	r *http.Request,
+	open func(ctx context.Context, attachmentID entity.ID) (
+		*entity.Attachment, io.ReadCloser, error),
+) (*entity.Attachment, io.ReadCloser, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "attachmentID"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+
+	viewer, err := s.signer.Verify(r.URL.Path, r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, "invalid or expired link", http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	ctx := middlewares.WithAuthToken(r.Context(), &entity.AuthToken{UserID: viewer})
+
+	attachment, content, err := open(ctx, entity.ID(id))
+	switch {
+	case errors.Is(err, domainerrors.ErrNotFound), errors.Is(err, domainerrors.ErrForbidden):
+		http.NotFound(w, r)
+		return nil, nil, false
+	case err != nil:
+		log.Printf("failed to open attachment: %v\n", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	return attachment, content, true
+}
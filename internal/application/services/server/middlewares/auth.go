@@ -32,6 +32,12 @@ func (a *Authenticator) GetAuthTokenFromContext(
 	return token, nil
 }
 
+// WithAuthToken authenticates the context as the token, for the requests
+// which prove their user otherwise than by the Authorization header.
+func WithAuthToken(ctx context.Context, token *entity.AuthToken) context.Context {
+	return context.WithValue(ctx, accessKeyAuthToken, token)
+}
+
 func parseAuthorizationHeader(
 	ctx context.Context,
 	authManager AuthManager,
@@ -72,7 +78,7 @@ func NewAuthenticationHandler(
 					return
 				}
 
-				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), accessKeyAuthToken, token)))
+				next.ServeHTTP(w, r.WithContext(WithAuthToken(r.Context(), token)))
 			},
 		)
 	}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,6 +18,8 @@ import (
 	"github.com/rs/cors"
 	"github.com/samthehai/chat/internal/application/services/server/middlewares"
 	"github.com/samthehai/chat/internal/interfaces/graph/generated"
+	"github.com/samthehai/chat/internal/interfaces/graph/loader"
+	"github.com/samthehai/chat/internal/interfaces/graph/model"
 	"github.com/samthehai/chat/internal/interfaces/graph/resolver"
 )
 
@@ -28,34 +31,61 @@ type Server interface {
 	Serve() error
 }
 
+// PresenceTracker follows the websocket connections of users, the returned
+// context replaces the one of the connection.
+type PresenceTracker interface {
+	Track(ctx context.Context) (context.Context, error)
+}
+
 type ServerOption struct {
 	Port               int
 	CORSAllowedOrigins []string
 	Environment        string
 	DebugUser          string
+	MaxUploadSize      int64
+	// AdminAddr is where the metrics are served, apart from the public
+	// listener. Empty disables it.
+	AdminAddr string
 }
 
 type server struct {
 	resolvers   resolver.Resolver
 	authManager middlewares.AuthManager
+	loaders     *loader.Factory
+	presence    PresenceTracker
+	attachments AttachmentOpener
+	signer      *model.AttachmentURLSigner
 	httpServer  *http.Server
+	adminServer *http.Server
 	options     ServerOption
 }
 
 func NewServer(
 	resolvers resolver.Resolver,
 	authManager middlewares.AuthManager,
+	loaders *loader.Factory,
+	presence PresenceTracker,
+	attachments AttachmentOpener,
+	signer *model.AttachmentURLSigner,
 	options ServerOption,
 ) (Server, func()) {
 	svr := &server{
 		resolvers:   resolvers,
 		authManager: authManager,
+		loaders:     loaders,
+		presence:    presence,
+		attachments: attachments,
+		signer:      signer,
 		options:     options,
 	}
 	cleaner := func() {
 		if svr.httpServer != nil {
 			_ = svr.httpServer.Shutdown(context.Background())
 		}
+
+		if svr.adminServer != nil {
+			_ = svr.adminServer.Shutdown(context.Background())
+		}
 	}
 
 	return svr, cleaner
@@ -69,6 +99,10 @@ func (s *server) Serve() error {
 	s.registerRoutes(router)
 	s.httpServer = &http.Server{Addr: fmt.Sprintf(":%v", s.options.Port), Handler: router}
 
+	if s.options.AdminAddr != "" {
+		s.serveAdmin()
+	}
+
 	if err := s.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("failed to serve: %w", err)
 	}
@@ -76,6 +110,22 @@ func (s *server) Serve() error {
 	return nil
 }
 
+// serveAdmin serves the metrics on the admin listener in the background, a
+// failure of it does not stop the public one.
+func (s *server) serveAdmin() {
+	log.Printf("runnning admin server at: %v ...\n", s.options.AdminAddr)
+
+	router := chi.NewRouter()
+	router.Handle("/debug/vars", expvar.Handler())
+	s.adminServer = &http.Server{Addr: s.options.AdminAddr, Handler: router}
+
+	go func() {
+		if err := s.adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("failed to serve admin: %v\n", err)
+		}
+	}()
+}
+
 func (s *server) registerMiddlewares(router *chi.Mux) {
 	router.Use(cors.New(cors.Options{
 		// AllowedOrigins:   s.options.CORSAllowedOrigins,
@@ -84,23 +134,38 @@ func (s *server) registerMiddlewares(router *chi.Mux) {
 		AllowedMethods:   []string{"GET", "POST"},
 		AllowCredentials: true,
 	}).Handler)
-	router.Use(middlewares.NewAuthenticationHandler(s.authManager, s.options.Environment == "development", s.options.DebugUser))
 }
 
 func (s *server) registerRoutes(router *chi.Mux) {
-	router.Handle("/", playground.Handler("GraphQL playground", graphqlEndpoint))
-	// router.Handle(graphqlEndpoint, s.newGraphQLServer())
-	router.Handle(graphqlEndpoint, s.newWebSocketGraphQLServer())
+	router.Group(func(router chi.Router) {
+		router.Use(middlewares.NewAuthenticationHandler(s.authManager, s.options.Environment == "development", s.options.DebugUser))
+		router.Handle("/", playground.Handler("GraphQL playground", graphqlEndpoint))
+		// router.Handle(graphqlEndpoint, s.newGraphQLServer())
+		router.Handle(graphqlEndpoint, s.newWebSocketGraphQLServer())
+	})
+
+	// the attachments are authenticated by the signature of their URL
+	router.Get(model.AttachmentsEndpoint+"/{attachmentID}", s.downloadAttachment)
+	router.Get(model.AttachmentsEndpoint+"/{attachmentID}/thumbnail", s.downloadThumbnail)
 }
 
 func (s *server) newGraphQLServer() *handler.Server {
-	return handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: &s.resolvers}))
+	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: &s.resolvers}))
+	srv.AroundOperations(s.loaders.AroundOperations)
+	srv.AroundResponses(s.loaders.AroundResponses)
+
+	return srv
 }
 
 func (s *server) newWebSocketGraphQLServer() *handler.Server {
 	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: &s.resolvers}))
 
 	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{
+		// room for the other parts of the request besides the file
+		MaxUploadSize: s.options.MaxUploadSize + 1<<20,
+		MaxMemory:     32 << 20,
+	})
 	srv.AddTransport(transport.Websocket{
 		KeepAlivePingInterval: 10 * time.Second,
 		Upgrader: websocket.Upgrader{
@@ -108,9 +173,22 @@ func (s *server) newWebSocketGraphQLServer() *handler.Server {
 				return true
 			},
 		},
+		// the context of the upgrade request lasts as long as the connection
+		InitFunc: func(ctx context.Context, _ transport.InitPayload) (context.Context, error) {
+			tracked, err := s.presence.Track(ctx)
+			if err != nil {
+				// presence is best effort, the connection goes on without it
+				log.Printf("failed to track presence: %v\n", err)
+				return ctx, nil
+			}
+
+			return tracked, nil
+		},
 	})
 
 	srv.Use(extension.Introspection{})
+	srv.AroundOperations(s.loaders.AroundOperations)
+	srv.AroundResponses(s.loaders.AroundResponses)
 
 	return srv
 }
@@ -35,3 +35,21 @@ func (id ID) MarshalGQL(w io.Writer) {
 func (id ID) Raw() interface{} {
 	return uint64(id)
 }
+
+// Cursor is an opaque position in a paginated list.
+type Cursor string
+
+func (c *Cursor) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("cursor must be a string")
+	}
+
+	*c = Cursor(str)
+
+	return nil
+}
+
+func (c Cursor) MarshalGQL(w io.Writer) {
+	_, _ = fmt.Fprint(w, strconv.Quote(string(c)))
+}
@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"io"
+	"time"
+)
+
+// Attachment is a file sent along a message, its content lives in the blob
+// store under StorageKey. Images are processed after being posted, they get
+// their dimensions and a thumbnail unless they can not be decoded.
+type Attachment struct {
+	ID                   ID         `json:"id"`
+	MessageID            ID         `json:"message_id"`
+	ConversationID       ID         `json:"conversation_id"`
+	UploaderID           ID         `json:"uploader_id"`
+	FileName             string     `json:"file_name"`
+	ContentType          string     `json:"content_type"`
+	Size                 int64      `json:"size"`
+	StorageKey           string     `json:"-"`
+	Width                *int       `json:"width"`
+	Height               *int       `json:"height"`
+	ThumbnailKey         *string    `json:"-"`
+	ThumbnailContentType *string    `json:"thumbnail_content_type"`
+	ProcessedAt          *time.Time `json:"processed_at"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// FileUpload is a file received from a client, its name and content are not
+// trusted.
+type FileUpload struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+// imageContentTypes are the sniffed content types sent as image messages,
+// any other content is sent as a file.
+var imageContentTypes = []string{
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+}
+
+func ImageContentTypes() []string {
+	return append([]string{}, imageContentTypes...)
+}
+
+// MessageTypeOfContent returns the type of the message carrying content of
+// the sniffed content type.
+func MessageTypeOfContent(contentType string) MessageType {
+	for _, t := range imageContentTypes {
+		if t == contentType {
+			return MessageTypeImage
+		}
+	}
+
+	return MessageTypeFile
+}
+
+func (a *Attachment) IsImage() bool {
+	return MessageTypeOfContent(a.ContentType) == MessageTypeImage
+}
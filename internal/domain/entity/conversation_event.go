@@ -0,0 +1,21 @@
+package entity
+
+type ConversationAction string
+
+const (
+	ConversationActionRenamed            ConversationAction = "CONVERSATION_ACTION_RENAMED"
+	ConversationActionParticipantsAdded  ConversationAction = "CONVERSATION_ACTION_PARTICIPANTS_ADDED"
+	ConversationActionParticipantRemoved ConversationAction = "CONVERSATION_ACTION_PARTICIPANT_REMOVED"
+	ConversationActionParticipantLeft    ConversationAction = "CONVERSATION_ACTION_PARTICIPANT_LEFT"
+	ConversationActionRoleChanged        ConversationAction = "CONVERSATION_ACTION_ROLE_CHANGED"
+	ConversationActionDeleted            ConversationAction = "CONVERSATION_ACTION_DELETED"
+)
+
+// ConversationEvent tells that a user changed a conversation, UserIDs are the
+// participants the change added, removed or changed the role of.
+type ConversationEvent struct {
+	Conversation *Conversation      `json:"conversation"`
+	Action       ConversationAction `json:"action"`
+	ActorID      ID                 `json:"actor_id"`
+	UserIDs      []ID               `json:"user_ids"`
+}
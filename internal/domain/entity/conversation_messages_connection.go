@@ -7,6 +7,6 @@ type ConversationMessagesConnection struct {
 }
 
 type ConversationMessagesEdge struct {
-	Cursor ID       `json:"cursor"`
+	Cursor Cursor   `json:"cursor"`
 	Node   *Message `json:"node"`
 }
@@ -7,6 +7,6 @@ type ConversationsConnection struct {
 }
 
 type ConversationsEdge struct {
-	Cursor ID            `json:"cursor"`
+	Cursor Cursor        `json:"cursor"`
 	Node   *Conversation `json:"node"`
 }
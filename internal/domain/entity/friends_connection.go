@@ -7,6 +7,6 @@ type FriendsConnection struct {
 }
 
 type FriendsEdge struct {
-	Cursor ID    `json:"cursor"`
-	Node   *User `json:"node"`
+	Cursor Cursor `json:"cursor"`
+	Node   *User  `json:"node"`
 }
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+)
+
+// Friendship is what a user did towards another one. Two friends both have
+// an accepted friendship towards each other.
+type Friendship struct {
+	UserID    ID               `json:"user_id"`
+	FriendID  ID               `json:"friend_id"`
+	Status    FriendshipStatus `json:"status"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+type FriendshipStatus string
+
+const (
+	FriendshipStatusPending  FriendshipStatus = "FRIENDSHIP_STATUS_PENDING"
+	FriendshipStatusAccepted FriendshipStatus = "FRIENDSHIP_STATUS_ACCEPTED"
+	FriendshipStatusBlocked  FriendshipStatus = "FRIENDSHIP_STATUS_BLOCKED"
+)
+
+func friendshipStatuses() []FriendshipStatus {
+	return []FriendshipStatus{
+		FriendshipStatusPending,
+		FriendshipStatusAccepted,
+		FriendshipStatusBlocked,
+	}
+}
+
+func IsValidFriendshipStatus(fs string) bool {
+	for _, s := range friendshipStatuses() {
+		if s == FriendshipStatus(fs) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FriendRequest is a pending friendship seen from the user it was sent to.
+type FriendRequest struct {
+	SenderID  ID        `json:"sender_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -7,6 +7,6 @@ type IDsConnection struct {
 }
 
 type IDsEdge struct {
-	Cursor ID `json:"cursor"`
-	Node   ID `json:"node"`
+	Cursor Cursor `json:"cursor"`
+	Node   ID     `json:"node"`
 }
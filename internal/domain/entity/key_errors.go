@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeyErrors is what the batched reads return when some of their keys failed,
+// e.g. the viewer may not see them, alongside the results of the others. It
+// maps each failed key to its error.
+type KeyErrors map[ID]error
+
+func (e KeyErrors) Error() string {
+	ids := make([]ID, 0, len(e))
+	for id := range e {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	messages := make([]string, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, fmt.Sprintf("%v: %v", id, e[id]))
+	}
+
+	return "failed keys: " + strings.Join(messages, ", ")
+}
+
+// Err returns the errors as an error, nil when no key failed.
+func (e KeyErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxLinksPerMessage bounds the links of a message which get a preview.
+const maxLinksPerMessage = 5
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+// LinkPreview is the metadata a page gives about itself for being shared,
+// from its OpenGraph or Twitter card tags.
+type LinkPreview struct {
+	URL         string  `json:"url"`
+	SiteName    *string `json:"site_name"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	ImageURL    *string `json:"image_url"`
+}
+
+// ExtractLinks returns the distinct http links of the text in order of
+// appearance, up to maxLinksPerMessage. Punctuation ending a sentence is not
+// taken as part of a link.
+func ExtractLinks(text string) []string {
+	var links []string
+
+	seen := make(map[string]bool)
+
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		link = trimLink(link)
+		if seen[link] || strings.HasSuffix(strings.ToLower(link), "://") {
+			continue
+		}
+
+		seen[link] = true
+		links = append(links, link)
+
+		if len(links) == maxLinksPerMessage {
+			break
+		}
+	}
+
+	return links
+}
+
+// trimLink drops the punctuation ending the link, closing parentheses are
+// kept when they are balanced as in https://en.wikipedia.org/wiki/Go_(game).
+func trimLink(link string) string {
+	for len(link) > 0 {
+		last := link[len(link)-1]
+
+		switch {
+		case strings.IndexByte(".,;:!?'\"]}", last) >= 0:
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		default:
+			return link
+		}
+
+		link = link[:len(link)-1]
+	}
+
+	return link
+}
@@ -1,8 +1,96 @@
 package entity
 
+import (
+	"fmt"
+
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+const (
+	DefaultPageSize = 10
+	// MaxPageSize bounds first and last, so that one query can not read a
+	// whole table.
+	MaxPageSize = 100
+)
+
+// ListQueryInput follows the relay connection arguments, First/After page
+// forward and Last/Before page backward. A zero value means unset.
 type ListQueryInput struct {
 	First     int
-	After     ID
+	After     Cursor
+	Last      int
+	Before    Cursor
 	SortBy    string
 	SortOrder SortOrderType
 }
+
+func NewListQueryInput(
+	first *int,
+	after *Cursor,
+	last *int,
+	before *Cursor,
+	sortBy string,
+	sortOrder SortOrderType,
+) (ListQueryInput, error) {
+	input := ListQueryInput{
+		SortBy:    sortBy,
+		SortOrder: sortOrder,
+	}
+
+	if first != nil {
+		input.First = *first
+	}
+
+	if after != nil {
+		input.After = *after
+	}
+
+	if last != nil {
+		input.Last = *last
+	}
+
+	if before != nil {
+		input.Before = *before
+	}
+
+	if first == nil && last == nil {
+		if input.Before != "" {
+			input.Last = DefaultPageSize
+		} else {
+			input.First = DefaultPageSize
+		}
+	}
+
+	if err := input.Validate(); err != nil {
+		return ListQueryInput{}, err
+	}
+
+	return input, nil
+}
+
+func (i ListQueryInput) Validate() error {
+	switch {
+	case i.First < 0 || i.Last < 0:
+		return fmt.Errorf("first and last must not be negative: %w",
+			domainerrors.ErrInvalid)
+	case i.First > MaxPageSize || i.Last > MaxPageSize:
+		return fmt.Errorf("first and last must not be over %v: %w", MaxPageSize,
+			domainerrors.ErrInvalid)
+	case i.First > 0 && i.Last > 0:
+		return fmt.Errorf("first and last can not be used together: %w",
+			domainerrors.ErrInvalid)
+	case i.After != "" && i.Before != "":
+		return fmt.Errorf("after and before can not be used together: %w",
+			domainerrors.ErrInvalid)
+	case i.Last > 0 && i.After != "", i.First > 0 && i.Before != "":
+		return fmt.Errorf("first pages after a cursor, last pages before a cursor: %w",
+			domainerrors.ErrInvalid)
+	}
+
+	return nil
+}
+
+// IsBackward reports whether the page is taken before the cursor.
+func (i ListQueryInput) IsBackward() bool {
+	return i.Last > 0 || i.Before != ""
+}
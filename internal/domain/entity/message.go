@@ -12,18 +12,35 @@ type Message struct {
 	Content        string      `json:"content"`
 	CreatedAt      time.Time   `json:"created_at"`
 	UpdatedAt      time.Time   `json:"updated_at"`
+	EditedAt       *time.Time  `json:"edited_at"`
 	DeletedAt      *time.Time  `json:"deleted_at"`
+	ParentID       *ID         `json:"parent_id"`
+	ReplyCount     int         `json:"reply_count"`
+	LastReplyAt    *time.Time  `json:"last_reply_at"`
+}
+
+// MessageRevision is a content a message had before being edited, CreatedAt
+// is when that content was written.
+type MessageRevision struct {
+	ID        ID        `json:"id"`
+	MessageID ID        `json:"message_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type MessageType string
 
 const (
-	MessageTypeText MessageType = "MESSAGE_TYPE_TEXT"
+	MessageTypeText  MessageType = "MESSAGE_TYPE_TEXT"
+	MessageTypeImage MessageType = "MESSAGE_TYPE_IMAGE"
+	MessageTypeFile  MessageType = "MESSAGE_TYPE_FILE"
 )
 
 func messageTypes() []MessageType {
 	return []MessageType{
 		MessageTypeText,
+		MessageTypeImage,
+		MessageTypeFile,
 	}
 }
 
@@ -40,3 +57,7 @@ func IsValidMessageType(mt string) bool {
 func (m *Message) GetID() ID {
 	return m.ID
 }
+
+func (m *Message) Edited() bool {
+	return m.EditedAt != nil
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// MessageSearchQuery selects the messages matching Text, optionally narrowed
+// to a conversation, a sender and a period.
+type MessageSearchQuery struct {
+	Text           string
+	ConversationID *ID
+	SenderID       *ID
+	SentBefore     *time.Time
+	SentAfter      *time.Time
+	ListQueryInput
+}
+
+type MessageSearchConnection struct {
+	PageInfo   *PageInfo            `json:"pageInfo"`
+	Edges      []*MessageSearchEdge `json:"edges"`
+	TotalCount int                  `json:"totalCount"`
+}
+
+type MessageSearchEdge struct {
+	Cursor Cursor   `json:"cursor"`
+	Node   *Message `json:"node"`
+	// Snippet is the part of the content around the matches, HTML escaped
+	// with the matches wrapped in <mark>.
+	Snippet string `json:"snippet"`
+}
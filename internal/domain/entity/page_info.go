@@ -1,6 +1,8 @@
 package entity
 
 type PageInfo struct {
-	HasPreviousPage bool `json:"hasPreviousPage"`
-	HasNextPage     bool `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	HasNextPage     bool    `json:"hasNextPage"`
+	StartCursor     *Cursor `json:"startCursor"`
+	EndCursor       *Cursor `json:"endCursor"`
 }
@@ -0,0 +1,107 @@
+package entity
+
+// ParticipantRole is the authority of a participant over a group
+// conversation. Every group has one owner, the participants of a single
+// conversation are all members.
+type ParticipantRole string
+
+const (
+	ParticipantRoleOwner  ParticipantRole = "PARTICIPANT_ROLE_OWNER"
+	ParticipantRoleAdmin  ParticipantRole = "PARTICIPANT_ROLE_ADMIN"
+	ParticipantRoleMember ParticipantRole = "PARTICIPANT_ROLE_MEMBER"
+)
+
+func participantRoles() []ParticipantRole {
+	return []ParticipantRole{
+		ParticipantRoleOwner,
+		ParticipantRoleAdmin,
+		ParticipantRoleMember,
+	}
+}
+
+func IsValidParticipantRole(role string) bool {
+	for _, r := range participantRoles() {
+		if r == ParticipantRole(role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Outranks reports whether the role is above the other one, a participant
+// only acts on the ones they outrank.
+func (r ParticipantRole) Outranks(other ParticipantRole) bool {
+	return r.rank() > other.rank()
+}
+
+func (r ParticipantRole) rank() int {
+	switch r {
+	case ParticipantRoleOwner:
+		return 3
+	case ParticipantRoleAdmin:
+		return 2
+	case ParticipantRoleMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type Permission string
+
+const (
+	PermissionRenameConversation   Permission = "RENAME_CONVERSATION"
+	PermissionAddParticipants      Permission = "ADD_PARTICIPANTS"
+	PermissionRemoveParticipants   Permission = "REMOVE_PARTICIPANTS"
+	PermissionDeleteOthersMessages Permission = "DELETE_OTHERS_MESSAGES"
+	PermissionPinMessages          Permission = "PIN_MESSAGES"
+	PermissionManageRoles          Permission = "MANAGE_ROLES"
+	PermissionDeleteConversation   Permission = "DELETE_CONVERSATION"
+)
+
+// groupPermissions is what each role may do in a group conversation.
+var groupPermissions = map[ParticipantRole][]Permission{
+	ParticipantRoleOwner: {
+		PermissionRenameConversation,
+		PermissionAddParticipants,
+		PermissionRemoveParticipants,
+		PermissionDeleteOthersMessages,
+		PermissionPinMessages,
+		PermissionManageRoles,
+		PermissionDeleteConversation,
+	},
+	ParticipantRoleAdmin: {
+		PermissionRenameConversation,
+		PermissionAddParticipants,
+		PermissionRemoveParticipants,
+		PermissionDeleteOthersMessages,
+		PermissionPinMessages,
+	},
+	ParticipantRoleMember: {},
+}
+
+// singlePermissions is what both participants of a single conversation may
+// do, they have no authority over each other. Neither may delete it, as that
+// would erase it for the other one too.
+var singlePermissions = []Permission{
+	PermissionRenameConversation,
+	PermissionPinMessages,
+}
+
+// Permits reports whether a participant with the role may do what the
+// permission stands for in the conversation.
+func (c *Conversation) Permits(role ParticipantRole, permission Permission) bool {
+	permissions := singlePermissions
+	if c.Type == ConversationTypeGroup {
+		permissions = groupPermissions[role]
+	}
+
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
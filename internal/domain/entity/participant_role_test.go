@@ -0,0 +1,102 @@
+package entity
+
+import "testing"
+
+func TestConversationPermits(t *testing.T) {
+	group := &Conversation{Type: ConversationTypeGroup}
+	single := &Conversation{Type: ConversationTypeSingle}
+
+	permissions := []Permission{
+		PermissionRenameConversation,
+		PermissionAddParticipants,
+		PermissionRemoveParticipants,
+		PermissionDeleteOthersMessages,
+		PermissionPinMessages,
+		PermissionManageRoles,
+		PermissionDeleteConversation,
+	}
+
+	tests := []struct {
+		name         string
+		conversation *Conversation
+		role         ParticipantRole
+		permitted    []Permission
+	}{
+		{
+			name:         "group owner",
+			conversation: group,
+			role:         ParticipantRoleOwner,
+			permitted:    permissions,
+		},
+		{
+			name:         "group admin",
+			conversation: group,
+			role:         ParticipantRoleAdmin,
+			permitted: []Permission{
+				PermissionRenameConversation,
+				PermissionAddParticipants,
+				PermissionRemoveParticipants,
+				PermissionDeleteOthersMessages,
+				PermissionPinMessages,
+			},
+		},
+		{
+			name:         "group member",
+			conversation: group,
+			role:         ParticipantRoleMember,
+		},
+		{
+			name:         "group unknown role",
+			conversation: group,
+			role:         ParticipantRole("PARTICIPANT_ROLE_UNKNOWN"),
+		},
+		{
+			name:         "single member",
+			conversation: single,
+			role:         ParticipantRoleMember,
+			permitted: []Permission{
+				PermissionRenameConversation,
+				PermissionPinMessages,
+			},
+		},
+		{
+			// a single conversation has no owner, a stale role grants nothing
+			name:         "single owner",
+			conversation: single,
+			role:         ParticipantRoleOwner,
+			permitted: []Permission{
+				PermissionRenameConversation,
+				PermissionPinMessages,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		permitted := make(map[Permission]bool, len(tt.permitted))
+		for _, p := range tt.permitted {
+			permitted[p] = true
+		}
+
+		for _, p := range permissions {
+			if got := tt.conversation.Permits(tt.role, p); got != permitted[p] {
+				t.Errorf("%v: %v: got %v, want %v", tt.name, p, got, permitted[p])
+			}
+		}
+	}
+}
+
+func TestParticipantRoleOutranks(t *testing.T) {
+	roles := []ParticipantRole{
+		ParticipantRoleMember,
+		ParticipantRoleAdmin,
+		ParticipantRoleOwner,
+	}
+
+	for i, r := range roles {
+		for j, other := range roles {
+			if got, want := r.Outranks(other), i > j; got != want {
+				t.Errorf("%v outranks %v: got %v, want %v", r, other, got, want)
+			}
+		}
+	}
+}
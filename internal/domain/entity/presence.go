@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+)
+
+// Presence is whether a user is connected, LastSeenAt is nil for a user who
+// never connected.
+type Presence struct {
+	UserID     ID             `json:"user_id"`
+	Status     PresenceStatus `json:"status"`
+	LastSeenAt *time.Time     `json:"last_seen_at"`
+}
+
+type PresenceStatus string
+
+const (
+	PresenceStatusOnline  PresenceStatus = "PRESENCE_STATUS_ONLINE"
+	PresenceStatusAway    PresenceStatus = "PRESENCE_STATUS_AWAY"
+	PresenceStatusOffline PresenceStatus = "PRESENCE_STATUS_OFFLINE"
+)
+
+func presenceStatuses() []PresenceStatus {
+	return []PresenceStatus{
+		PresenceStatusOnline,
+		PresenceStatusAway,
+		PresenceStatusOffline,
+	}
+}
+
+func IsValidPresenceStatus(ps string) bool {
+	for _, s := range presenceStatuses() {
+		if s == PresenceStatus(ps) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxEmojiLength bounds the runes of an emoji, joined sequences such as
+// families or flags take several of them.
+const maxEmojiLength = 16
+
+// ReactionGroup counts the reactions sharing an emoji on a message,
+// ViewerReacted tells whether the current user is among them.
+type ReactionGroup struct {
+	Emoji         string `json:"emoji"`
+	Count         int    `json:"count"`
+	ViewerReacted bool   `json:"viewer_reacted"`
+}
+
+// ReactionEvent tells that a user added or removed a reaction on a message.
+type ReactionEvent struct {
+	Message *Message `json:"message"`
+	UserID  ID       `json:"user_id"`
+	Emoji   string   `json:"emoji"`
+	Added   bool     `json:"added"`
+}
+
+func IsValidEmoji(emoji string) bool {
+	if emoji == "" || !utf8.ValidString(emoji) ||
+		utf8.RuneCountInString(emoji) > maxEmojiLength {
+		return false
+	}
+
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+)
+
+// ReadReceipt tells how far a participant has read a conversation, every
+// message up to LastReadMessageID is read. Nil fields mean nothing is read.
+type ReadReceipt struct {
+	ConversationID    ID         `json:"conversation_id"`
+	UserID            ID         `json:"user_id"`
+	LastReadMessageID *ID        `json:"last_read_message_id"`
+	ReadAt            *time.Time `json:"read_at"`
+}
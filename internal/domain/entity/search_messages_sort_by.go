@@ -0,0 +1,25 @@
+package entity
+
+type SearchMessagesSortByType string
+
+const (
+	SearchMessagesSortByTypeRelevance SearchMessagesSortByType = "SEARCH_MESSAGES_SORT_BY_RELEVANCE"
+	SearchMessagesSortByTypeCreatedAt SearchMessagesSortByType = "SEARCH_MESSAGES_SORT_BY_CREATED_AT"
+)
+
+func SearchMessagesSortByTypes() []SearchMessagesSortByType {
+	return []SearchMessagesSortByType{
+		SearchMessagesSortByTypeRelevance,
+		SearchMessagesSortByTypeCreatedAt,
+	}
+}
+
+func IsValidSearchMessagesSortByType(sortBy string) bool {
+	for _, t := range SearchMessagesSortByTypes() {
+		if string(t) == sortBy {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,8 @@
+package entity
+
+// TypingEvent tells that a user started or stopped typing in a conversation.
+type TypingEvent struct {
+	ConversationID ID   `json:"conversation_id"`
+	UserID         ID   `json:"user_id"`
+	Typing         bool `json:"typing"`
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+// maxFileNameLength bounds in bytes the names kept for attachments.
+const maxFileNameLength = 255
+
+// AttachmentOption limits in bytes the size of the uploaded files.
+type AttachmentOption struct {
+	MaxFileSize  int64
+	MaxImageSize int64
+}
+
+// PostAttachment posts the uploaded file as a message of the conversation,
+// text being its caption. The message is an image or a file depending on the
+// sniffed content, the type declared by the client is not trusted.
+func (u *MessageUsecase) PostAttachment(
+	ctx context.Context,
+	conversationID entity.ID,
+	senderID entity.ID,
+	upload entity.FileUpload,
+	text string,
+	replyToID *entity.ID,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("PostAttachment: %w", err)
+	}
+
+	if err := authorizeParticipant(ctx, u.messageRepository, senderID,
+		conversationID); err != nil {
+		return fail(fmt.Errorf("authorize participant: %w", err))
+	}
+
+	content, err := u.readUpload(upload)
+	if err != nil {
+		return fail(err)
+	}
+
+	contentType := http.DetectContentType(content)
+	msgType := entity.MessageTypeOfContent(contentType)
+
+	if msgType == entity.MessageTypeImage && int64(len(content)) > u.attachmentOption.MaxImageSize {
+		return fail(fmt.Errorf("image larger than %v bytes: %w",
+			u.attachmentOption.MaxImageSize, domainerrors.ErrInvalid))
+	}
+
+	key, size, err := u.attachmentRepository.StoreContent(ctx, content, contentType)
+	if err != nil {
+		return fail(fmt.Errorf("store content: %w", err))
+	}
+
+	var (
+		message    *entity.Message
+		attachment *entity.Attachment
+	)
+
+	if err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		if replyToID != nil {
+			if err := u.validateParent(txCtx, conversationID, *replyToID); err != nil {
+				return fmt.Errorf("validate parent: %w", err)
+			}
+		}
+
+		created, err := u.messageRepository.CreateMessageWithTransaction(txCtx,
+			conversationID, msgType, senderID, text, replyToID)
+		if err != nil {
+			return fmt.Errorf("create message: %w", err)
+		}
+
+		attachment, err = u.attachmentRepository.CreateAttachmentWithTransaction(txCtx,
+			&entity.Attachment{
+				MessageID:   created.ID,
+				UploaderID:  senderID,
+				FileName:    sanitizeFileName(upload.FileName),
+				ContentType: contentType,
+				Size:        size,
+				StorageKey:  key,
+			})
+		if err != nil {
+			return fmt.Errorf("create attachment: %w", err)
+		}
+
+		message = created
+
+		return nil
+	}); err != nil {
+		// skip error when discarding the content, it is attached to nothing
+		_ = u.attachmentRepository.DiscardContent(ctx, key)
+		return fail(err)
+	}
+
+	// skip error when fanout message
+	_ = u.messageRepository.FanoutMessage(ctx, message)
+	u.fanoutThread(ctx, message)
+	u.enqueueLinks(message)
+
+	if attachment.ProcessedAt == nil {
+		u.enqueueImage(attachment)
+	}
+
+	return message, nil
+}
+
+// OpenAttachment returns the attachment with its content for the current
+// user, who must take part in its conversation.
+func (u *MessageUsecase) OpenAttachment(
+	ctx context.Context,
+	attachmentID entity.ID,
+) (*entity.Attachment, io.ReadCloser, error) {
+	fail := func(err error) (*entity.Attachment, io.ReadCloser, error) {
+		return nil, nil, fmt.Errorf("OpenAttachment: %w", err)
+	}
+
+	attachment, err := u.viewableAttachment(ctx, attachmentID)
+	if err != nil {
+		return fail(err)
+	}
+
+	content, err := u.attachmentRepository.OpenContent(ctx, attachment.StorageKey)
+	if err != nil {
+		return fail(fmt.Errorf("open content: %w", err))
+	}
+
+	return attachment, content, nil
+}
+
+// OpenThumbnail is OpenAttachment for the thumbnail of an image, which is
+// not found until the image is processed.
+func (u *MessageUsecase) OpenThumbnail(
+	ctx context.Context,
+	attachmentID entity.ID,
+) (*entity.Attachment, io.ReadCloser, error) {
+	fail := func(err error) (*entity.Attachment, io.ReadCloser, error) {
+		return nil, nil, fmt.Errorf("OpenThumbnail: %w", err)
+	}
+
+	attachment, err := u.viewableAttachment(ctx, attachmentID)
+	if err != nil {
+		return fail(err)
+	}
+
+	if attachment.ThumbnailKey == nil {
+		return fail(fmt.Errorf("thumbnail of attachment %v: %w", attachmentID,
+			domainerrors.ErrNotFound))
+	}
+
+	content, err := u.attachmentRepository.OpenContent(ctx, *attachment.ThumbnailKey)
+	if err != nil {
+		return fail(fmt.Errorf("open content: %w", err))
+	}
+
+	return attachment, content, nil
+}
+
+// viewableAttachment returns the attachment once checked that the current
+// user takes part in its conversation.
+func (u *MessageUsecase) viewableAttachment(
+	ctx context.Context,
+	attachmentID entity.ID,
+) (*entity.Attachment, error) {
+	attachment, err := u.attachmentRepository.FindAttachment(ctx, attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("find attachment: %w", err)
+	}
+
+	if err := authorizeViewer(ctx, u.userRepository, u.messageRepository,
+		attachment.ConversationID); err != nil {
+		return nil, fmt.Errorf("authorize viewer: %w", err)
+	}
+
+	return attachment, nil
+}
+
+func (u *MessageUsecase) AttachmentsOfMessages(ctx context.Context,
+	messageIDs []entity.ID) (map[entity.ID][]*entity.Attachment, error) {
+	allowed, keyErrors, err := u.authorizeMessagesPerKey(ctx, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize messages: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID][]*entity.Attachment{}, keyErrors.Err()
+	}
+
+	res, err := u.attachmentRepository.FindAttachments(ctx, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("find attachments: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
+
+// readUpload reads the whole uploaded content, which must be neither empty
+// nor larger than the file size limit.
+func (u *MessageUsecase) readUpload(upload entity.FileUpload) ([]byte, error) {
+	maxSize := u.attachmentOption.MaxFileSize
+	if upload.Size > maxSize {
+		return nil, fmt.Errorf("file larger than %v bytes: %w", maxSize,
+			domainerrors.ErrInvalid)
+	}
+
+	// the declared size is not trusted either, one byte more tells it is over
+	content, err := ioutil.ReadAll(io.LimitReader(upload.Content, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+
+	switch {
+	case len(content) == 0:
+		return nil, fmt.Errorf("file is empty: %w", domainerrors.ErrInvalid)
+	case int64(len(content)) > maxSize:
+		return nil, fmt.Errorf("file larger than %v bytes: %w", maxSize,
+			domainerrors.ErrInvalid)
+	}
+
+	return content, nil
+}
+
+// sanitizeFileName keeps the base name of what the client sent, without
+// control characters and within maxFileNameLength.
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+
+		return r
+	}, name)
+
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+
+	for len(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "file"
+	}
+
+	return name
+}
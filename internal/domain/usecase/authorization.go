@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/domain/usecase/repository"
+)
+
+// authorizeParticipant returns ErrForbidden unless the user takes part in
+// every given conversation.
+func authorizeParticipant(
+	ctx context.Context,
+	messageRepository repository.MessageRepository,
+	userID entity.ID,
+	conversationIDs ...entity.ID,
+) error {
+	if len(conversationIDs) == 0 {
+		return nil
+	}
+
+	ids, err := messageRepository.FindConversationIDsByParticipant(ctx, userID,
+		conversationIDs)
+	if err != nil {
+		return fmt.Errorf("find conversation ids by participant: %w", err)
+	}
+
+	joined := make(map[entity.ID]bool, len(ids))
+	for _, id := range ids {
+		joined[id] = true
+	}
+
+	for _, id := range conversationIDs {
+		if !joined[id] {
+			return fmt.Errorf("user %v is not a participant of conversation %v: %w",
+				userID, id, domainerrors.ErrForbidden)
+		}
+	}
+
+	return nil
+}
+
+// authorizeViewer is authorizeParticipant for the user of the request.
+func authorizeViewer(
+	ctx context.Context,
+	userRepository repository.UserRepository,
+	messageRepository repository.MessageRepository,
+	conversationIDs ...entity.ID,
+) error {
+	user, err := userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return fmt.Errorf("user is nil")
+	}
+
+	return authorizeParticipant(ctx, messageRepository, user.ID, conversationIDs...)
+}
+
+// authorizeParticipantPerKey is authorizeParticipant for the keys of a
+// batch, so that a forbidden key fails alone. conversationOf gives the
+// conversation of each key, the keys missing from it are not found. It
+// returns the keys the user may see and the errors of the others.
+func authorizeParticipantPerKey(
+	ctx context.Context,
+	messageRepository repository.MessageRepository,
+	userID entity.ID,
+	keys []entity.ID,
+	conversationOf map[entity.ID]entity.ID,
+) ([]entity.ID, entity.KeyErrors, error) {
+	conversationIDs := make([]entity.ID, 0, len(conversationOf))
+	for _, id := range conversationOf {
+		conversationIDs = append(conversationIDs, id)
+	}
+
+	joinedIDs, err := messageRepository.FindConversationIDsByParticipant(ctx,
+		userID, conversationIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find conversation ids by participant: %w", err)
+	}
+
+	joined := make(map[entity.ID]bool, len(joinedIDs))
+	for _, id := range joinedIDs {
+		joined[id] = true
+	}
+
+	allowed := make([]entity.ID, 0, len(keys))
+	keyErrors := make(entity.KeyErrors)
+
+	for _, key := range keys {
+		conversationID, ok := conversationOf[key]
+		switch {
+		case !ok:
+			keyErrors[key] = fmt.Errorf("key %v: %w", key, domainerrors.ErrNotFound)
+		case !joined[conversationID]:
+			keyErrors[key] = fmt.Errorf("user %v is not a participant of conversation %v: %w",
+				userID, conversationID, domainerrors.ErrForbidden)
+		default:
+			allowed = append(allowed, key)
+		}
+	}
+
+	return allowed, keyErrors, nil
+}
+
+// authorizeViewerPerKey is authorizeParticipantPerKey for the user of the
+// request.
+func authorizeViewerPerKey(
+	ctx context.Context,
+	userRepository repository.UserRepository,
+	messageRepository repository.MessageRepository,
+	keys []entity.ID,
+	conversationOf map[entity.ID]entity.ID,
+) ([]entity.ID, entity.KeyErrors, error) {
+	user, err := userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, nil, fmt.Errorf("user is nil")
+	}
+
+	return authorizeParticipantPerKey(ctx, messageRepository, user.ID, keys,
+		conversationOf)
+}
+
+// conversationsOfThemselves maps the conversations to themselves, for
+// authorizeViewerPerKey with batches keyed by conversation.
+func conversationsOfThemselves(conversationIDs []entity.ID) map[entity.ID]entity.ID {
+	res := make(map[entity.ID]entity.ID, len(conversationIDs))
+	for _, id := range conversationIDs {
+		res[id] = id
+	}
+
+	return res
+}
@@ -0,0 +1,519 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+const maxConversationTitleLength = 255
+
+// conversationChange is a conversation locked for a change by one of its
+// participants.
+type conversationChange struct {
+	conversation *entity.Conversation
+	participants []*entity.Participant
+	actor        *entity.Participant
+}
+
+// participant returns the participant who is the user, nil when none is.
+func (c *conversationChange) participant(userID entity.ID) *entity.Participant {
+	for _, p := range c.participants {
+		if p.UserID == userID {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// authorize returns ErrForbidden unless the actor has the permission.
+func (c *conversationChange) authorize(permission entity.Permission) error {
+	if !c.conversation.Permits(c.actor.Role, permission) {
+		return fmt.Errorf("user %v may not %v in conversation %v: %w",
+			c.actor.UserID, permission, c.conversation.ID, domainerrors.ErrForbidden)
+	}
+
+	return nil
+}
+
+// RenameConversation sets the title of a conversation.
+func (u *MessageUsecase) RenameConversation(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	title string,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("RenameConversation: %w", err)
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > maxConversationTitleLength {
+		return fail(fmt.Errorf("title must have 1 to %d characters: %w",
+			maxConversationTitleLength, domainerrors.ErrInvalid))
+	}
+
+	conversation, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if err := c.authorize(entity.PermissionRenameConversation); err != nil {
+				return nil, err
+			}
+
+			if err := u.messageRepository.RenameConversationWithTransaction(txCtx,
+				conversationID, title); err != nil {
+				return nil, fmt.Errorf("rename conversation: %w", err)
+			}
+
+			return []*entity.ConversationEvent{
+				{Action: entity.ConversationActionRenamed},
+			}, nil
+		})
+	if err != nil {
+		return fail(err)
+	}
+
+	return conversation, nil
+}
+
+// AddParticipants adds users to a group conversation as members, the users
+// already taking part are skipped.
+func (u *MessageUsecase) AddParticipants(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	participantIDs []entity.ID,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("AddParticipants: %w", err)
+	}
+
+	if len(participantIDs) == 0 {
+		return fail(fmt.Errorf("no user to add: %w", domainerrors.ErrInvalid))
+	}
+
+	users, err := u.userRepository.FindUsers(ctx, participantIDs)
+	if err != nil {
+		return fail(fmt.Errorf("find users: %w", err))
+	}
+
+	found := make(map[entity.ID]bool, len(users))
+	for _, user := range users {
+		found[user.ID] = true
+	}
+
+	for _, id := range participantIDs {
+		if !found[id] {
+			return fail(fmt.Errorf("user %v: %w", id, domainerrors.ErrInvalid))
+		}
+	}
+
+	conversation, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if c.conversation.Type != entity.ConversationTypeGroup {
+				return nil, fmt.Errorf("participants can only be added to a group: %w",
+					domainerrors.ErrInvalid)
+			}
+
+			if err := c.authorize(entity.PermissionAddParticipants); err != nil {
+				return nil, err
+			}
+
+			added, err := u.messageRepository.AddParticipantsWithTransaction(txCtx,
+				conversationID, participantIDs)
+			if err != nil {
+				return nil, fmt.Errorf("add participants: %w", err)
+			}
+
+			if len(added) == 0 {
+				return nil, nil
+			}
+
+			return []*entity.ConversationEvent{
+				{Action: entity.ConversationActionParticipantsAdded, UserIDs: added},
+			}, nil
+		})
+	if err != nil {
+		return fail(err)
+	}
+
+	return conversation, nil
+}
+
+// RemoveParticipant takes a user out of a group conversation, the actor must
+// outrank them. Participants leave by themselves with LeaveConversation.
+func (u *MessageUsecase) RemoveParticipant(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	participantID entity.ID,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("RemoveParticipant: %w", err)
+	}
+
+	if participantID == userID {
+		return fail(fmt.Errorf("leave the conversation instead of removing yourself: %w",
+			domainerrors.ErrInvalid))
+	}
+
+	conversation, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if err := c.authorize(entity.PermissionRemoveParticipants); err != nil {
+				return nil, err
+			}
+
+			target := c.participant(participantID)
+			if target == nil {
+				return nil, fmt.Errorf("user %v is not a participant of conversation %v: %w",
+					participantID, conversationID, domainerrors.ErrNotFound)
+			}
+
+			if !c.actor.Role.Outranks(target.Role) {
+				return nil, fmt.Errorf("user %v does not outrank user %v: %w",
+					userID, participantID, domainerrors.ErrForbidden)
+			}
+
+			if _, err := u.messageRepository.RemoveParticipantWithTransaction(txCtx,
+				conversationID, participantID); err != nil {
+				return nil, fmt.Errorf("remove participant: %w", err)
+			}
+
+			return []*entity.ConversationEvent{
+				{Action: entity.ConversationActionParticipantRemoved,
+					UserIDs: []entity.ID{participantID}},
+			}, nil
+		})
+	if err != nil {
+		return fail(err)
+	}
+
+	return conversation, nil
+}
+
+// LeaveConversation takes the user out of a group conversation. When the
+// owner leaves, the participant of the highest role who joined first takes
+// over the ownership. A group left by its last participant is deleted.
+func (u *MessageUsecase) LeaveConversation(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+) error {
+	_, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if c.conversation.Type != entity.ConversationTypeGroup {
+				return nil, fmt.Errorf("only a group can be left: %w", domainerrors.ErrInvalid)
+			}
+
+			if _, err := u.messageRepository.RemoveParticipantWithTransaction(txCtx,
+				conversationID, userID); err != nil {
+				return nil, fmt.Errorf("remove participant: %w", err)
+			}
+
+			events := []*entity.ConversationEvent{
+				{Action: entity.ConversationActionParticipantLeft, UserIDs: []entity.ID{userID}},
+			}
+
+			if len(c.participants) == 1 {
+				if err := u.messageRepository.DeleteConversationWithTransaction(txCtx,
+					conversationID); err != nil {
+					return nil, fmt.Errorf("delete conversation: %w", err)
+				}
+
+				// nobody takes part anymore, the user who left is told
+				return append(events, &entity.ConversationEvent{
+					Action:  entity.ConversationActionDeleted,
+					UserIDs: []entity.ID{userID},
+				}), nil
+			}
+
+			if c.actor.Role != entity.ParticipantRoleOwner {
+				return events, nil
+			}
+
+			var successor *entity.Participant
+			for _, p := range c.participants {
+				if p.UserID != userID && (successor == nil || p.Role.Outranks(successor.Role)) {
+					successor = p
+				}
+			}
+
+			if successor == nil {
+				return events, nil
+			}
+
+			if err := u.messageRepository.SetParticipantRoleWithTransaction(txCtx,
+				conversationID, successor.UserID, entity.ParticipantRoleOwner); err != nil {
+				return nil, fmt.Errorf("transfer ownership: %w", err)
+			}
+
+			return append(events, &entity.ConversationEvent{
+				Action:  entity.ConversationActionRoleChanged,
+				UserIDs: []entity.ID{successor.UserID},
+			}), nil
+		})
+	if err != nil {
+		return fmt.Errorf("LeaveConversation: %w", err)
+	}
+
+	return nil
+}
+
+// PromoteParticipant raises a member to admin. Promoting an admin transfers
+// the ownership to them, the owner becoming an admin.
+func (u *MessageUsecase) PromoteParticipant(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	participantID entity.ID,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("PromoteParticipant: %w", err)
+	}
+
+	conversation, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if err := c.authorize(entity.PermissionManageRoles); err != nil {
+				return nil, err
+			}
+
+			target := c.participant(participantID)
+			if target == nil {
+				return nil, fmt.Errorf("user %v is not a participant of conversation %v: %w",
+					participantID, conversationID, domainerrors.ErrNotFound)
+			}
+
+			switch target.Role {
+			case entity.ParticipantRoleMember:
+				if err := u.messageRepository.SetParticipantRoleWithTransaction(txCtx,
+					conversationID, participantID, entity.ParticipantRoleAdmin); err != nil {
+					return nil, fmt.Errorf("set role: %w", err)
+				}
+
+				return []*entity.ConversationEvent{
+					{Action: entity.ConversationActionRoleChanged,
+						UserIDs: []entity.ID{participantID}},
+				}, nil
+			case entity.ParticipantRoleAdmin:
+				// the owner steps down first, a conversation has one owner
+				if err := u.messageRepository.SetParticipantRoleWithTransaction(txCtx,
+					conversationID, userID, entity.ParticipantRoleAdmin); err != nil {
+					return nil, fmt.Errorf("set role: %w", err)
+				}
+
+				if err := u.messageRepository.SetParticipantRoleWithTransaction(txCtx,
+					conversationID, participantID, entity.ParticipantRoleOwner); err != nil {
+					return nil, fmt.Errorf("set role: %w", err)
+				}
+
+				return []*entity.ConversationEvent{
+					{Action: entity.ConversationActionRoleChanged,
+						UserIDs: []entity.ID{participantID, userID}},
+				}, nil
+			default:
+				return nil, fmt.Errorf("user %v can not be promoted further: %w",
+					participantID, domainerrors.ErrInvalid)
+			}
+		})
+	if err != nil {
+		return fail(err)
+	}
+
+	return conversation, nil
+}
+
+// DemoteParticipant lowers an admin to member. The owner hands over the
+// ownership by promoting an admin instead.
+func (u *MessageUsecase) DemoteParticipant(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	participantID entity.ID,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("DemoteParticipant: %w", err)
+	}
+
+	conversation, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if err := c.authorize(entity.PermissionManageRoles); err != nil {
+				return nil, err
+			}
+
+			target := c.participant(participantID)
+			if target == nil {
+				return nil, fmt.Errorf("user %v is not a participant of conversation %v: %w",
+					participantID, conversationID, domainerrors.ErrNotFound)
+			}
+
+			if target.Role != entity.ParticipantRoleAdmin {
+				return nil, fmt.Errorf("only an admin can be demoted: %w",
+					domainerrors.ErrInvalid)
+			}
+
+			if err := u.messageRepository.SetParticipantRoleWithTransaction(txCtx,
+				conversationID, participantID, entity.ParticipantRoleMember); err != nil {
+				return nil, fmt.Errorf("set role: %w", err)
+			}
+
+			return []*entity.ConversationEvent{
+				{Action: entity.ConversationActionRoleChanged,
+					UserIDs: []entity.ID{participantID}},
+			}, nil
+		})
+	if err != nil {
+		return fail(err)
+	}
+
+	return conversation, nil
+}
+
+// DeleteConversation soft deletes a group conversation, it disappears for
+// every participant. Single conversations can not be deleted.
+func (u *MessageUsecase) DeleteConversation(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+) error {
+	_, err := u.changeConversation(ctx, userID, conversationID,
+		func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error) {
+			if err := c.authorize(entity.PermissionDeleteConversation); err != nil {
+				return nil, err
+			}
+
+			if err := u.messageRepository.DeleteConversationWithTransaction(txCtx,
+				conversationID); err != nil {
+				return nil, fmt.Errorf("delete conversation: %w", err)
+			}
+
+			return []*entity.ConversationEvent{
+				{Action: entity.ConversationActionDeleted},
+			}, nil
+		})
+	if err != nil {
+		return fmt.Errorf("DeleteConversation: %w", err)
+	}
+
+	return nil
+}
+
+// changeConversation runs change on the conversation locked in a transaction,
+// once the user is known to take part in it. change returns the events
+// telling about what it did, they are published after the commit.
+func (u *MessageUsecase) changeConversation(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	change func(txCtx context.Context, c *conversationChange) ([]*entity.ConversationEvent, error),
+) (*entity.Conversation, error) {
+	var (
+		conversation *entity.Conversation
+		events       []*entity.ConversationEvent
+	)
+
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		c, err := u.lockConversation(txCtx, userID, conversationID)
+		if err != nil {
+			return err
+		}
+
+		events, err = change(txCtx, c)
+		if err != nil {
+			return err
+		}
+
+		conversations, err := u.messageRepository.FindConversationsByIDsWithTransaction(txCtx,
+			[]entity.ID{conversationID})
+		if err != nil {
+			return fmt.Errorf("find conversations: %w", err)
+		}
+
+		if len(conversations) == 0 {
+			return fmt.Errorf("conversation %v: %w", conversationID, domainerrors.ErrNotFound)
+		}
+
+		conversation = conversations[0]
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return conversation, nil
+	}
+
+	// typing is authorized against the cached participants, they must not
+	// outlive the change
+	if err := u.messageRepository.RenewParticipantIDsGeneration(ctx,
+		conversationID); err != nil {
+		return nil, fmt.Errorf("renew participant ids generation: %w", err)
+	}
+
+	for _, event := range events {
+		event.Conversation = conversation
+		event.ActorID = userID
+
+		// skip error when fanout conversation event
+		_ = u.messageRepository.FanoutConversationEvent(ctx, event)
+	}
+
+	return conversation, nil
+}
+
+// lockConversation locks the conversation in the transaction of txCtx, the
+// user must take part in it.
+func (u *MessageUsecase) lockConversation(
+	txCtx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+) (*conversationChange, error) {
+	conversation, err := u.messageRepository.LockConversationWithTransaction(txCtx,
+		conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("lock conversation: %w", err)
+	}
+
+	if conversation.DeletedAt != nil {
+		return nil, fmt.Errorf("conversation %v: %w", conversationID, domainerrors.ErrNotFound)
+	}
+
+	participants, err := u.messageRepository.FindParticipantsWithTransaction(txCtx,
+		conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("find participants: %w", err)
+	}
+
+	c := &conversationChange{
+		conversation: conversation,
+		participants: participants,
+	}
+
+	c.actor = c.participant(userID)
+	if c.actor == nil {
+		return nil, fmt.Errorf("user %v is not a participant of conversation %v: %w",
+			userID, conversationID, domainerrors.ErrForbidden)
+	}
+
+	return c, nil
+}
+
+func (u *MessageUsecase) ConversationUpdated(
+	ctx context.Context) (<-chan *entity.ConversationEvent, error) {
+	user, err := u.subscriber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.messageRepository.ConversationUpdated(ctx, *user)
+	if err != nil {
+		return nil, fmt.Errorf("conversation updated: %w", err)
+	}
+
+	return events, nil
+}
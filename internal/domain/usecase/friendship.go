@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/domain/usecase/repository"
+)
+
+type FriendshipUsecase struct {
+	userRepository       repository.UserRepository
+	friendshipRepository repository.FriendshipRepository
+	transactor           repository.Transactor
+}
+
+func NewFriendshipUsecase(
+	userRepository repository.UserRepository,
+	friendshipRepository repository.FriendshipRepository,
+	transactor repository.Transactor,
+) *FriendshipUsecase {
+	return &FriendshipUsecase{
+		userRepository:       userRepository,
+		friendshipRepository: friendshipRepository,
+		transactor:           transactor,
+	}
+}
+
+// SendFriendRequest asks friendID to become a friend of userID. A request
+// already sent the other way round is accepted instead, and a block of
+// userID towards friendID is lifted.
+func (u *FriendshipUsecase) SendFriendRequest(
+	ctx context.Context,
+	userID entity.ID,
+	friendID entity.ID,
+) (*entity.Friendship, error) {
+	fail := func(err error) (*entity.Friendship, error) {
+		return nil, fmt.Errorf("SendFriendRequest: %w", err)
+	}
+
+	if err := u.validateCounterpart(ctx, userID, friendID); err != nil {
+		return fail(err)
+	}
+
+	var friendship *entity.Friendship
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		outgoing, incoming, err := u.findFriendships(txCtx, userID, friendID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case hasFriendshipStatus(incoming, entity.FriendshipStatusBlocked):
+			return fmt.Errorf("user %v blocked user %v: %w",
+				friendID, userID, domainerrors.ErrForbidden)
+		case hasFriendshipStatus(outgoing, entity.FriendshipStatusAccepted):
+			friendship = outgoing
+			return nil
+		case hasFriendshipStatus(incoming, entity.FriendshipStatusPending):
+			friendship, err = u.befriend(txCtx, userID, friendID)
+			return err
+		}
+
+		friendship, err = u.friendshipRepository.SaveFriendshipWithTransaction(txCtx,
+			userID, friendID, entity.FriendshipStatusPending)
+		if err != nil {
+			return fmt.Errorf("save friendship: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	return friendship, nil
+}
+
+// AcceptFriendRequest makes userID and senderID friends, senderID must have
+// sent a friend request to userID.
+func (u *FriendshipUsecase) AcceptFriendRequest(
+	ctx context.Context,
+	userID entity.ID,
+	senderID entity.ID,
+) (*entity.Friendship, error) {
+	fail := func(err error) (*entity.Friendship, error) {
+		return nil, fmt.Errorf("AcceptFriendRequest: %w", err)
+	}
+
+	var friendship *entity.Friendship
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		if err := u.findPendingRequest(txCtx, userID, senderID); err != nil {
+			return err
+		}
+
+		var err error
+		friendship, err = u.befriend(txCtx, userID, senderID)
+
+		return err
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	return friendship, nil
+}
+
+// DeclineFriendRequest drops the friend request senderID sent to userID.
+func (u *FriendshipUsecase) DeclineFriendRequest(
+	ctx context.Context,
+	userID entity.ID,
+	senderID entity.ID,
+) error {
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		if err := u.findPendingRequest(txCtx, userID, senderID); err != nil {
+			return err
+		}
+
+		if err := u.friendshipRepository.DeleteFriendshipWithTransaction(txCtx,
+			senderID, userID); err != nil {
+			return fmt.Errorf("delete friendship: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("DeclineFriendRequest: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFriend drops whatever userID did towards friendID: the friendship,
+// a friend request sent or a block. A block of friendID towards userID is
+// kept.
+func (u *FriendshipUsecase) RemoveFriend(
+	ctx context.Context,
+	userID entity.ID,
+	friendID entity.ID,
+) error {
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		_, incoming, err := u.findFriendships(txCtx, userID, friendID)
+		if err != nil {
+			return err
+		}
+
+		if err := u.friendshipRepository.DeleteFriendshipWithTransaction(txCtx,
+			userID, friendID); err != nil {
+			return fmt.Errorf("delete friendship: %w", err)
+		}
+
+		if hasFriendshipStatus(incoming, entity.FriendshipStatusAccepted) {
+			if err := u.friendshipRepository.DeleteFriendshipWithTransaction(txCtx,
+				friendID, userID); err != nil {
+				return fmt.Errorf("delete friendship: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("RemoveFriend: %w", err)
+	}
+
+	return nil
+}
+
+// BlockUser ends any friendship between userID and blockedID and keeps
+// blockedID from sending friend requests to userID.
+func (u *FriendshipUsecase) BlockUser(
+	ctx context.Context,
+	userID entity.ID,
+	blockedID entity.ID,
+) (*entity.Friendship, error) {
+	fail := func(err error) (*entity.Friendship, error) {
+		return nil, fmt.Errorf("BlockUser: %w", err)
+	}
+
+	if err := u.validateCounterpart(ctx, userID, blockedID); err != nil {
+		return fail(err)
+	}
+
+	var friendship *entity.Friendship
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		_, incoming, err := u.findFriendships(txCtx, userID, blockedID)
+		if err != nil {
+			return err
+		}
+
+		if incoming != nil && incoming.Status != entity.FriendshipStatusBlocked {
+			if err := u.friendshipRepository.DeleteFriendshipWithTransaction(txCtx,
+				blockedID, userID); err != nil {
+				return fmt.Errorf("delete friendship: %w", err)
+			}
+		}
+
+		friendship, err = u.friendshipRepository.SaveFriendshipWithTransaction(txCtx,
+			userID, blockedID, entity.FriendshipStatusBlocked)
+		if err != nil {
+			return fmt.Errorf("save friendship: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	return friendship, nil
+}
+
+func (u *FriendshipUsecase) PendingFriendRequests(
+	ctx context.Context,
+	userID entity.ID,
+) ([]*entity.FriendRequest, error) {
+	requests, err := u.friendshipRepository.FindPendingFriendRequests(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("find pending friend requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// validateCounterpart checks that otherID is an existing user other than
+// userID.
+func (u *FriendshipUsecase) validateCounterpart(
+	ctx context.Context,
+	userID entity.ID,
+	otherID entity.ID,
+) error {
+	if userID == otherID {
+		return fmt.Errorf("user %v can not target itself: %w",
+			userID, domainerrors.ErrInvalid)
+	}
+
+	users, err := u.userRepository.FindUsers(ctx, []entity.ID{otherID})
+	if err != nil {
+		return fmt.Errorf("find users: %w", err)
+	}
+
+	if len(users) == 0 {
+		return fmt.Errorf("user %v: %w", otherID, domainerrors.ErrNotFound)
+	}
+
+	return nil
+}
+
+// findFriendships returns what userID did towards otherID and what otherID
+// did towards userID. Both are locked until the end of the transaction, even
+// when there are none yet.
+func (u *FriendshipUsecase) findFriendships(
+	txCtx context.Context,
+	userID entity.ID,
+	otherID entity.ID,
+) (outgoing *entity.Friendship, incoming *entity.Friendship, err error) {
+	if err := u.friendshipRepository.LockFriendshipsWithTransaction(txCtx,
+		userID, otherID); err != nil {
+		return nil, nil, fmt.Errorf("lock friendships: %w", err)
+	}
+
+	outgoing, err = u.friendshipRepository.FindFriendshipWithTransaction(txCtx,
+		userID, otherID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find friendship: %w", err)
+	}
+
+	incoming, err = u.friendshipRepository.FindFriendshipWithTransaction(txCtx,
+		otherID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find friendship: %w", err)
+	}
+
+	return outgoing, incoming, nil
+}
+
+// findPendingRequest checks that senderID sent a friend request to userID,
+// the friendships between them are locked until the end of the transaction.
+func (u *FriendshipUsecase) findPendingRequest(
+	txCtx context.Context,
+	userID entity.ID,
+	senderID entity.ID,
+) error {
+	if err := u.friendshipRepository.LockFriendshipsWithTransaction(txCtx,
+		userID, senderID); err != nil {
+		return fmt.Errorf("lock friendships: %w", err)
+	}
+
+	request, err := u.friendshipRepository.FindFriendshipWithTransaction(txCtx,
+		senderID, userID)
+	if err != nil {
+		return fmt.Errorf("find friendship: %w", err)
+	}
+
+	if !hasFriendshipStatus(request, entity.FriendshipStatusPending) {
+		return fmt.Errorf("friend request from user %v: %w",
+			senderID, domainerrors.ErrNotFound)
+	}
+
+	return nil
+}
+
+// befriend saves the accepted friendships of both users and returns the one
+// of userID.
+func (u *FriendshipUsecase) befriend(
+	txCtx context.Context,
+	userID entity.ID,
+	friendID entity.ID,
+) (*entity.Friendship, error) {
+	if _, err := u.friendshipRepository.SaveFriendshipWithTransaction(txCtx,
+		friendID, userID, entity.FriendshipStatusAccepted); err != nil {
+		return nil, fmt.Errorf("save friendship: %w", err)
+	}
+
+	friendship, err := u.friendshipRepository.SaveFriendshipWithTransaction(txCtx,
+		userID, friendID, entity.FriendshipStatusAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("save friendship: %w", err)
+	}
+
+	return friendship, nil
+}
+
+func hasFriendshipStatus(friendship *entity.Friendship,
+	status entity.FriendshipStatus) bool {
+	return friendship != nil && friendship.Status == status
+}
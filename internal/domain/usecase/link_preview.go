@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+const (
+	// linkWorkers is how many messages get their links fetched at once.
+	linkWorkers = 2
+	// linkQueueSize bounds the messages waiting for their links to be
+	// fetched, the ones which do not fit get no preview.
+	linkQueueSize = 64
+)
+
+// startLinkPipeline fetches the previews of the posted links in the
+// background.
+func (u *MessageUsecase) startLinkPipeline() {
+	for i := 0; i < linkWorkers; i++ {
+		go func() {
+			for message := range u.links {
+				u.unfurlMessage(context.Background(), message)
+			}
+		}()
+	}
+}
+
+// enqueueLinks has the previews of the links of the message fetched unless
+// the queue is full.
+func (u *MessageUsecase) enqueueLinks(message *entity.Message) {
+	if len(entity.ExtractLinks(message.Content)) == 0 {
+		return
+	}
+
+	select {
+	case u.links <- message:
+	default:
+	}
+}
+
+// unfurlMessage fetches the previews of the links of the message, it is
+// published as updated when one was added so that clients get it.
+func (u *MessageUsecase) unfurlMessage(ctx context.Context, message *entity.Message) {
+	added := false
+
+	for _, link := range entity.ExtractLinks(message.Content) {
+		// skip error, the link gets no preview
+		unfurled, _ := u.linkPreviewRepository.UnfurlLink(ctx, link)
+		added = added || unfurled
+	}
+
+	if !added {
+		return
+	}
+
+	messages, err := u.messageRepository.FindMessagesByIDs(ctx, []entity.ID{message.ID})
+	if err != nil {
+		return
+	}
+
+	for _, message := range messages {
+		// skip error when fanout message update
+		_ = u.messageRepository.FanoutMessageUpdate(ctx, message)
+	}
+}
+
+// LinkPreviewsOfMessages returns the previews of the links of each message
+// which were fetched, in the order of the links. The messages having links
+// out of the cache, e.g. expired, get them fetched again and are published
+// as updated once done.
+func (u *MessageUsecase) LinkPreviewsOfMessages(ctx context.Context,
+	messageIDs []entity.ID) (map[entity.ID][]*entity.LinkPreview, error) {
+	messages, err := u.messageRepository.FindMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("find messages: %w", err)
+	}
+
+	conversationOf := make(map[entity.ID]entity.ID, len(messages))
+	for _, message := range messages {
+		conversationOf[message.ID] = message.ConversationID
+	}
+
+	allowed, keyErrors, err := authorizeViewerPerKey(ctx, u.userRepository,
+		u.messageRepository, messageIDs, conversationOf)
+	if err != nil {
+		return nil, fmt.Errorf("authorize viewer: %w", err)
+	}
+
+	isAllowed := make(map[entity.ID]bool, len(allowed))
+	for _, id := range allowed {
+		isAllowed[id] = true
+	}
+
+	viewable := make([]*entity.Message, 0, len(allowed))
+	linksOfMessages := make(map[entity.ID][]string, len(allowed))
+
+	var links []string
+
+	for _, message := range messages {
+		if !isAllowed[message.ID] {
+			continue
+		}
+
+		viewable = append(viewable, message)
+		linksOfMessages[message.ID] = entity.ExtractLinks(message.Content)
+		links = append(links, linksOfMessages[message.ID]...)
+	}
+
+	previews, uncached, err := u.linkPreviewRepository.FindLinkPreviews(ctx, links)
+	if err != nil {
+		return nil, fmt.Errorf("find link previews: %w", err)
+	}
+
+	if len(uncached) > 0 {
+		u.enqueueUncachedLinks(viewable, linksOfMessages, uncached)
+	}
+
+	res := make(map[entity.ID][]*entity.LinkPreview, len(viewable))
+	for id, links := range linksOfMessages {
+		for _, link := range links {
+			if preview, ok := previews[link]; ok {
+				res[id] = append(res[id], preview)
+			}
+		}
+	}
+
+	return res, keyErrors.Err()
+}
+
+// enqueueUncachedLinks has the messages having one of the uncached links
+// unfurled again.
+func (u *MessageUsecase) enqueueUncachedLinks(messages []*entity.Message,
+	linksOfMessages map[entity.ID][]string, uncached []string) {
+	isUncached := make(map[string]bool, len(uncached))
+	for _, link := range uncached {
+		isUncached[link] = true
+	}
+
+	for _, message := range messages {
+		for _, link := range linksOfMessages[message.ID] {
+			if isUncached[link] {
+				u.enqueueLinks(message)
+				break
+			}
+		}
+	}
+}
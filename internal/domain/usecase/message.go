@@ -3,44 +3,80 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
 	"github.com/samthehai/chat/internal/domain/usecase/repository"
 )
 
 type MessageUsecase struct {
-	userRepository    repository.UserRepository
-	messageRepository repository.MessageRepository
-	transactor        repository.Transactor
+	userRepository        repository.UserRepository
+	messageRepository     repository.MessageRepository
+	attachmentRepository  repository.AttachmentRepository
+	linkPreviewRepository repository.LinkPreviewRepository
+	transactor            repository.Transactor
+	attachmentOption      AttachmentOption
+	images                chan *entity.Attachment
+	links                 chan *entity.Message
 }
 
 func NewMessageUsecase(
 	userRepository repository.UserRepository,
 	messageRepository repository.MessageRepository,
+	attachmentRepository repository.AttachmentRepository,
+	linkPreviewRepository repository.LinkPreviewRepository,
 	transactor repository.Transactor,
+	attachmentOption AttachmentOption,
 ) *MessageUsecase {
-	return &MessageUsecase{
-		userRepository:    userRepository,
-		messageRepository: messageRepository,
-		transactor:        transactor,
+	u := &MessageUsecase{
+		userRepository:        userRepository,
+		messageRepository:     messageRepository,
+		attachmentRepository:  attachmentRepository,
+		linkPreviewRepository: linkPreviewRepository,
+		transactor:            transactor,
+		attachmentOption:      attachmentOption,
+		images:                make(chan *entity.Attachment, imageQueueSize),
+		links:                 make(chan *entity.Message, linkQueueSize),
 	}
+
+	u.startImagePipeline()
+	u.startLinkPipeline()
+
+	return u
 }
 
+// PostMessage posts a message in the conversation, in the thread of
+// replyToID when given. Threads are one level deep and stay within the
+// conversation of their parent.
 func (u *MessageUsecase) PostMessage(
 	ctx context.Context,
 	conversationID entity.ID,
 	msgType entity.MessageType,
 	senderID entity.ID,
 	text string,
+	replyToID *entity.ID,
 ) (*entity.Message, error) {
+	if err := authorizeParticipant(ctx, u.messageRepository, senderID,
+		conversationID); err != nil {
+		return nil, fmt.Errorf("authorize participant: %w", err)
+	}
+
 	txCtx, err := u.transactor.Begin(ctx)
 	if err != nil {
 		return nil, errorHandlerWithTransaction(txCtx, u.transactor,
 			fmt.Errorf("begin transaction: %w", err))
 	}
 
+	if replyToID != nil {
+		if err := u.validateParent(txCtx, conversationID, *replyToID); err != nil {
+			return nil, errorHandlerWithTransaction(txCtx, u.transactor,
+				fmt.Errorf("validate parent: %w", err))
+		}
+	}
+
 	message, err := u.messageRepository.CreateMessageWithTransaction(txCtx, conversationID,
-		msgType, senderID, text)
+		msgType, senderID, text, replyToID)
 	if err != nil {
 		return nil, errorHandlerWithTransaction(txCtx, u.transactor,
 			fmt.Errorf("create message: %w", err))
@@ -52,11 +88,53 @@ func (u *MessageUsecase) PostMessage(
 	}
 
 	// skip error when fanout message
-	u.messageRepository.FanoutMessage(ctx, message)
+	_ = u.messageRepository.FanoutMessage(ctx, message)
+	u.fanoutThread(ctx, message)
+	u.enqueueLinks(message)
 
 	return message, nil
 }
 
+// validateParent checks that a message of the conversation may be replied
+// to, the parent stays locked until the end of the transaction.
+func (u *MessageUsecase) validateParent(ctx context.Context,
+	conversationID entity.ID, parentID entity.ID) error {
+	parent, err := u.messageRepository.FindMessageWithTransaction(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("find message: %w", err)
+	}
+
+	switch {
+	case parent.ConversationID != conversationID:
+		return fmt.Errorf("message %v is not in conversation %v: %w",
+			parentID, conversationID, domainerrors.ErrInvalid)
+	case parent.ParentID != nil:
+		return fmt.Errorf("message %v is a reply: %w", parentID, domainerrors.ErrInvalid)
+	case parent.DeletedAt != nil:
+		return fmt.Errorf("message %v: %w", parentID, domainerrors.ErrNotFound)
+	}
+
+	return nil
+}
+
+// fanoutThread publishes the parent of a reply as updated, its reply count
+// and last reply time having changed.
+func (u *MessageUsecase) fanoutThread(ctx context.Context, reply *entity.Message) {
+	if reply.ParentID == nil {
+		return
+	}
+
+	parents, err := u.messageRepository.FindMessagesByIDs(ctx, []entity.ID{*reply.ParentID})
+	if err != nil {
+		return
+	}
+
+	for _, parent := range parents {
+		// skip error when fanout message update
+		_ = u.messageRepository.FanoutMessageUpdate(ctx, parent)
+	}
+}
+
 func (u *MessageUsecase) CreateNewConversation(
 	ctx context.Context,
 	creatorID entity.ID,
@@ -82,9 +160,11 @@ func (u *MessageUsecase) CreateNewConversation(
 			fmt.Errorf("create conversation: %w", err)))
 	}
 
+	var message *entity.Message
+
 	if text != nil {
-		_, err := u.messageRepository.CreateMessageWithTransaction(txCtx, *conversationID,
-			entity.MessageTypeText, creatorID, *text)
+		message, err = u.messageRepository.CreateMessageWithTransaction(txCtx, *conversationID,
+			entity.MessageTypeText, creatorID, *text, nil)
 		if err != nil {
 			return nil, errorHandlerWithTransaction(txCtx, u.transactor,
 				fmt.Errorf("create message: %w", err))
@@ -108,11 +188,21 @@ func (u *MessageUsecase) CreateNewConversation(
 			fmt.Errorf("commit transaction: %w", err))
 	}
 
+	// a typing before the creation may have cached no participants
+	if err := u.messageRepository.RenewParticipantIDsGeneration(ctx,
+		*conversationID); err != nil {
+		return fail(fmt.Errorf("renew participant ids generation: %w", err))
+	}
+
+	if message != nil {
+		u.enqueueLinks(message)
+	}
+
 	return cc[0], nil
 }
 
-func (u *MessageUsecase) MessagePosted(ctx context.Context) (
-	<-chan *entity.Message, error) {
+func (u *MessageUsecase) MessagePosted(ctx context.Context,
+	conversationID *entity.ID) (<-chan *entity.Message, error) {
 	user, err := u.userRepository.GetUserFromContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get user from context: %w", err)
@@ -122,7 +212,14 @@ func (u *MessageUsecase) MessagePosted(ctx context.Context) (
 		return nil, fmt.Errorf("user is nil")
 	}
 
-	messages, err := u.messageRepository.MessagePosted(ctx, *user)
+	if conversationID != nil {
+		if err := authorizeParticipant(ctx, u.messageRepository, user.ID,
+			*conversationID); err != nil {
+			return nil, fmt.Errorf("authorize participant: %w", err)
+		}
+	}
+
+	messages, err := u.messageRepository.MessagePosted(ctx, *user, conversationID)
 	if err != nil {
 		return nil, fmt.Errorf("message posted: %w", err)
 	}
@@ -133,24 +230,43 @@ func (u *MessageUsecase) MessagePosted(ctx context.Context) (
 func (u *MessageUsecase) AllMessagesByConversationIDs(ctx context.Context,
 	conversationIDs []entity.ID) (
 	map[entity.ID][]*entity.Message, error) {
-	res, err := u.messageRepository.FindAllMessagesInConversations(ctx,
-		conversationIDs)
+	allowed, keyErrors, err := u.authorizeConversationsPerKey(ctx, conversationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize conversations: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID][]*entity.Message{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindAllMessagesInConversations(ctx, allowed)
 	if err != nil {
 		return nil, fmt.Errorf("find messages in conversations: %w", err)
 	}
 
-	return res, nil
+	return res, keyErrors.Err()
 }
 
+// ConversationByIDs returns the conversations the viewer takes part in, the
+// others fail alone.
 func (u *MessageUsecase) ConversationByIDs(ctx context.Context,
 	conversationIDs []entity.ID) ([]*entity.Conversation,
 	error) {
-	res, err := u.messageRepository.FindConversationsByIDs(ctx, conversationIDs)
+	allowed, keyErrors, err := u.authorizeConversationsPerKey(ctx, conversationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize conversations: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return []*entity.Conversation{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindConversationsByIDs(ctx, allowed)
 	if err != nil {
 		return nil, fmt.Errorf("find conversations: %w", err)
 	}
 
-	return res, nil
+	return res, keyErrors.Err()
 }
 
 func (u *MessageUsecase) Conversations(ctx context.Context) (
@@ -168,36 +284,341 @@ func (u *MessageUsecase) Conversations(ctx context.Context) (
 	return nil, nil
 }
 
+// GetConversationIDsFromUserIDs returns the conversations of each user, the
+// users other than the viewer are forbidden.
 func (u *MessageUsecase) GetConversationIDsFromUserIDs(
 	ctx context.Context,
 	inputs []entity.RelayQueryInput,
 ) (map[entity.ID]*entity.IDsConnection, error) {
-	users, err := u.messageRepository.FindConversationIDsFromUserIDs(ctx, inputs)
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user is nil")
+	}
+
+	allowed := make([]entity.RelayQueryInput, 0, len(inputs))
+	keyErrors := make(entity.KeyErrors)
+
+	for _, input := range inputs {
+		if input.KeyID != user.ID {
+			keyErrors[input.KeyID] = fmt.Errorf("conversations of user %v: %w",
+				input.KeyID, domainerrors.ErrForbidden)
+			continue
+		}
+
+		allowed = append(allowed, input)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID]*entity.IDsConnection{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindConversationIDsFromUserIDs(ctx, allowed)
 	if err != nil {
 		return nil, fmt.Errorf("find conversation ids from user ids: %w", err)
 	}
 
-	return users, nil
+	return res, keyErrors.Err()
 }
 
 func (u *MessageUsecase) GetParticipantsInConversations(ctx context.Context,
-	conversationIDs []entity.ID) (map[entity.ID][]*entity.User, error) {
+	conversationIDs []entity.ID) (map[entity.ID][]*entity.Participant, error) {
+	allowed, keyErrors, err := u.authorizeConversationsPerKey(ctx, conversationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize conversations: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID][]*entity.Participant{}, keyErrors.Err()
+	}
+
 	participants, err := u.messageRepository.FindParticipantsInConversations(ctx,
-		conversationIDs)
+		allowed)
 	if err != nil {
 		return nil, fmt.Errorf("find participants in conversations: %w", err)
 	}
 
-	return participants, nil
+	return participants, keyErrors.Err()
 }
 
 func (u *MessageUsecase) MessagesInConversations(ctx context.Context,
 	inputs []entity.RelayQueryInput,
 ) (map[entity.ID]*entity.ConversationMessagesConnection, error) {
-	res, err := u.messageRepository.FindMessagesInConversations(ctx, inputs)
+	allowed, keyErrors, err := u.authorizeConversationsPerKey(ctx, keyIDsOf(inputs))
+	if err != nil {
+		return nil, fmt.Errorf("authorize conversations: %w", err)
+	}
+
+	allowedInputs := inputsWithKeys(inputs, allowed)
+	if len(allowedInputs) == 0 {
+		return map[entity.ID]*entity.ConversationMessagesConnection{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindMessagesInConversations(ctx, allowedInputs)
 	if err != nil {
 		return nil, fmt.Errorf("find messages in conversations: %w", err)
 	}
 
-	return res, nil
+	return res, keyErrors.Err()
+}
+
+func (u *MessageUsecase) RepliesOfMessages(ctx context.Context,
+	inputs []entity.RelayQueryInput,
+) (map[entity.ID]*entity.ConversationMessagesConnection, error) {
+	allowed, keyErrors, err := u.authorizeMessagesPerKey(ctx, keyIDsOf(inputs))
+	if err != nil {
+		return nil, fmt.Errorf("authorize messages: %w", err)
+	}
+
+	allowedInputs := inputsWithKeys(inputs, allowed)
+	if len(allowedInputs) == 0 {
+		return map[entity.ID]*entity.ConversationMessagesConnection{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindRepliesOfMessages(ctx, allowedInputs)
+	if err != nil {
+		return nil, fmt.Errorf("find replies of messages: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
+
+// EditMessage replaces the content of a message, only its sender may do it.
+func (u *MessageUsecase) EditMessage(
+	ctx context.Context,
+	userID entity.ID,
+	messageID entity.ID,
+	text string,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("EditMessage: %w", err)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return fail(fmt.Errorf("empty text: %w", domainerrors.ErrInvalid))
+	}
+
+	var message *entity.Message
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		current, err := u.messageRepository.FindMessageWithTransaction(txCtx, messageID)
+		if err != nil {
+			return fmt.Errorf("find message: %w", err)
+		}
+
+		switch {
+		case current.SenderID != userID:
+			return fmt.Errorf("user %v is not the sender of message %v: %w",
+				userID, messageID, domainerrors.ErrForbidden)
+		case current.DeletedAt != nil:
+			return fmt.Errorf("message %v: %w", messageID, domainerrors.ErrNotFound)
+		case current.Content == text:
+			message = current
+			return nil
+		}
+
+		message, err = u.messageRepository.EditMessageWithTransaction(txCtx,
+			messageID, text)
+		if err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	// skip error when fanout message update
+	_ = u.messageRepository.FanoutMessageUpdate(ctx, message)
+	u.enqueueLinks(message)
+
+	return message, nil
+}
+
+func (u *MessageUsecase) MessageUpdated(ctx context.Context,
+	conversationID *entity.ID) (<-chan *entity.Message, error) {
+	user, err := u.subscriber(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := u.messageRepository.MessageUpdated(ctx, *user, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("message updated: %w", err)
+	}
+
+	return messages, nil
+}
+
+// DeleteMessage turns a message into a tombstone. The sender may delete it,
+// and so may the participants allowed to delete the messages of the ones
+// they outrank.
+func (u *MessageUsecase) DeleteMessage(
+	ctx context.Context,
+	userID entity.ID,
+	messageID entity.ID,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("DeleteMessage: %w", err)
+	}
+
+	var message *entity.Message
+	err := inTransaction(ctx, u.transactor, func(txCtx context.Context) error {
+		current, err := u.messageRepository.FindMessageWithTransaction(txCtx, messageID)
+		if err != nil {
+			return fmt.Errorf("find message: %w", err)
+		}
+
+		if current.DeletedAt != nil {
+			message = current
+			return nil
+		}
+
+		if current.SenderID != userID {
+			c, err := u.lockConversation(txCtx, userID, current.ConversationID)
+			if err != nil {
+				return err
+			}
+
+			if err := c.authorize(entity.PermissionDeleteOthersMessages); err != nil {
+				return err
+			}
+
+			// the sender may have left, then anyone allowed may delete
+			if sender := c.participant(current.SenderID); sender != nil &&
+				!c.actor.Role.Outranks(sender.Role) {
+				return fmt.Errorf("user %v may not delete message %v: %w",
+					userID, messageID, domainerrors.ErrForbidden)
+			}
+		}
+
+		message, err = u.messageRepository.DeleteMessageWithTransaction(txCtx, messageID)
+		if err != nil {
+			return fmt.Errorf("delete message: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	// skip error when fanout message deletion
+	_ = u.messageRepository.FanoutMessageDeletion(ctx, message)
+	u.fanoutThread(ctx, message)
+
+	return message, nil
+}
+
+func (u *MessageUsecase) MessageDeleted(ctx context.Context,
+	conversationID *entity.ID) (<-chan *entity.Message, error) {
+	user, err := u.subscriber(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := u.messageRepository.MessageDeleted(ctx, *user, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("message deleted: %w", err)
+	}
+
+	return messages, nil
+}
+
+// subscriber returns the current user, who must take part in conversationID
+// when given.
+func (u *MessageUsecase) subscriber(ctx context.Context,
+	conversationID *entity.ID) (*entity.User, error) {
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user is nil")
+	}
+
+	if conversationID != nil {
+		if err := authorizeParticipant(ctx, u.messageRepository, user.ID,
+			*conversationID); err != nil {
+			return nil, fmt.Errorf("authorize participant: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+func (u *MessageUsecase) RevisionsOfMessages(ctx context.Context,
+	messageIDs []entity.ID) (map[entity.ID][]*entity.MessageRevision, error) {
+	allowed, keyErrors, err := u.authorizeMessagesPerKey(ctx, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize messages: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID][]*entity.MessageRevision{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindMessageRevisions(ctx, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("find message revisions: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
+
+// authorizeConversationsPerKey is authorizeViewerPerKey for a batch keyed by
+// conversation.
+func (u *MessageUsecase) authorizeConversationsPerKey(ctx context.Context,
+	conversationIDs []entity.ID) ([]entity.ID, entity.KeyErrors, error) {
+	return authorizeViewerPerKey(ctx, u.userRepository, u.messageRepository,
+		conversationIDs, conversationsOfThemselves(conversationIDs))
+}
+
+// authorizeMessagesPerKey is authorizeViewerPerKey for a batch keyed by
+// message, the missing messages are not found.
+func (u *MessageUsecase) authorizeMessagesPerKey(ctx context.Context,
+	messageIDs []entity.ID) ([]entity.ID, entity.KeyErrors, error) {
+	messages, err := u.messageRepository.FindMessagesByIDs(ctx, messageIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find messages: %w", err)
+	}
+
+	conversationOf := make(map[entity.ID]entity.ID, len(messages))
+	for _, message := range messages {
+		conversationOf[message.ID] = message.ConversationID
+	}
+
+	return authorizeViewerPerKey(ctx, u.userRepository, u.messageRepository,
+		messageIDs, conversationOf)
+}
+
+func keyIDsOf(inputs []entity.RelayQueryInput) []entity.ID {
+	ids := make([]entity.ID, 0, len(inputs))
+	for _, input := range inputs {
+		ids = append(ids, input.KeyID)
+	}
+
+	return ids
+}
+
+// inputsWithKeys keeps the inputs of the given keys.
+func inputsWithKeys(inputs []entity.RelayQueryInput,
+	keyIDs []entity.ID) []entity.RelayQueryInput {
+	kept := make(map[entity.ID]bool, len(keyIDs))
+	for _, id := range keyIDs {
+		kept[id] = true
+	}
+
+	res := make([]entity.RelayQueryInput, 0, len(inputs))
+	for _, input := range inputs {
+		if kept[input.KeyID] {
+			res = append(res, input)
+		}
+	}
+
+	return res
 }
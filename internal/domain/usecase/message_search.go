@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+const maxSearchQueryLength = 256
+
+// SearchMessages pages the messages of the conversations of the user which
+// match the query.
+func (u *MessageUsecase) SearchMessages(
+	ctx context.Context,
+	userID entity.ID,
+	query entity.MessageSearchQuery,
+) (*entity.MessageSearchConnection, error) {
+	query.Text = strings.TrimSpace(query.Text)
+
+	if query.Text == "" {
+		return nil, fmt.Errorf("query must not be empty: %w", domainerrors.ErrInvalid)
+	}
+
+	if utf8.RuneCountInString(query.Text) > maxSearchQueryLength {
+		return nil, fmt.Errorf("query is longer than %d characters: %w",
+			maxSearchQueryLength, domainerrors.ErrInvalid)
+	}
+
+	if query.SentBefore != nil && query.SentAfter != nil &&
+		!query.SentAfter.Before(*query.SentBefore) {
+		return nil, fmt.Errorf("sentAfter must be before sentBefore: %w",
+			domainerrors.ErrInvalid)
+	}
+
+	if query.ConversationID != nil {
+		if err := authorizeParticipant(ctx, u.messageRepository, userID,
+			*query.ConversationID); err != nil {
+			return nil, fmt.Errorf("authorize participant: %w", err)
+		}
+	}
+
+	res, err := u.messageRepository.SearchMessages(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("search messages: %w", err)
+	}
+
+	return res, nil
+}
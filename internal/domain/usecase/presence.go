@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/domain/usecase/repository"
+)
+
+// presenceHeartbeatInterval is how often a live connection is refreshed, well
+// within the time it takes to expire.
+const presenceHeartbeatInterval = 20 * time.Second
+
+type connectionCtxKey struct{}
+
+// connection is a websocket connection of a user followed by Track.
+type connection struct {
+	id     string
+	userID entity.ID
+	status entity.PresenceStatus
+	mutex  sync.Mutex
+}
+
+func (c *connection) getStatus() entity.PresenceStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.status
+}
+
+func (c *connection) setStatus(status entity.PresenceStatus) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.status = status
+}
+
+type PresenceUsecase struct {
+	userRepository     repository.UserRepository
+	presenceRepository repository.PresenceRepository
+	sweeper            sync.Once
+}
+
+func NewPresenceUsecase(
+	userRepository repository.UserRepository,
+	presenceRepository repository.PresenceRepository,
+) *PresenceUsecase {
+	return &PresenceUsecase{
+		userRepository:     userRepository,
+		presenceRepository: presenceRepository,
+	}
+}
+
+// Track follows the connection of the user of ctx until ctx is done, the
+// returned context carries the connection so that SetPresence can change its
+// status.
+func (u *PresenceUsecase) Track(ctx context.Context) (context.Context, error) {
+	fail := func(err error) (context.Context, error) {
+		return nil, fmt.Errorf("Track: %w", err)
+	}
+
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return fail(fmt.Errorf("get user from context: %w", err))
+	}
+
+	if user == nil {
+		return fail(fmt.Errorf("user is nil"))
+	}
+
+	u.sweeper.Do(func() { go u.sweep() })
+
+	before, err := u.presence(ctx, user.ID)
+	if err != nil {
+		return fail(err)
+	}
+
+	id, err := u.presenceRepository.Connect(ctx, user.ID, entity.PresenceStatusOnline)
+	if err != nil {
+		return fail(fmt.Errorf("connect: %w", err))
+	}
+
+	conn := &connection{
+		id:     id,
+		userID: user.ID,
+		status: entity.PresenceStatusOnline,
+	}
+
+	u.publishChange(ctx, before)
+
+	go u.keepAlive(ctx, conn)
+
+	return context.WithValue(ctx, connectionCtxKey{}, conn), nil
+}
+
+// SetPresence sets the status of the connection the request came through.
+func (u *PresenceUsecase) SetPresence(
+	ctx context.Context,
+	status entity.PresenceStatus,
+) (*entity.Presence, error) {
+	fail := func(err error) (*entity.Presence, error) {
+		return nil, fmt.Errorf("SetPresence: %w", err)
+	}
+
+	conn, ok := ctx.Value(connectionCtxKey{}).(*connection)
+	if !ok {
+		return fail(fmt.Errorf("not a websocket connection: %w", domainerrors.ErrInvalid))
+	}
+
+	if status != entity.PresenceStatusOnline && status != entity.PresenceStatusAway {
+		return fail(fmt.Errorf("status %v: %w", status, domainerrors.ErrInvalid))
+	}
+
+	before, err := u.presence(ctx, conn.userID)
+	if err != nil {
+		return fail(err)
+	}
+
+	conn.setStatus(status)
+
+	if err := u.presenceRepository.RefreshConnection(ctx, conn.userID, conn.id,
+		status); err != nil {
+		return fail(fmt.Errorf("refresh connection: %w", err))
+	}
+
+	presence := u.publishChange(ctx, before)
+	if presence == nil {
+		return fail(fmt.Errorf("read presence of user %v", conn.userID))
+	}
+
+	return presence, nil
+}
+
+// Presences returns the presence of the users the current user may follow
+// with PresenceChanged, the others are forbidden.
+func (u *PresenceUsecase) Presences(ctx context.Context,
+	userIDs []entity.ID) (map[entity.ID]*entity.Presence, error) {
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user is nil")
+	}
+
+	seenIDs, err := u.presenceRepository.FindUserIDsSeenBy(ctx, user.ID, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("find user ids seen by: %w", err)
+	}
+
+	seen := make(map[entity.ID]bool, len(seenIDs))
+	for _, id := range seenIDs {
+		seen[id] = true
+	}
+
+	allowed := make([]entity.ID, 0, len(userIDs))
+	keyErrors := make(entity.KeyErrors)
+
+	for _, id := range userIDs {
+		if !seen[id] {
+			keyErrors[id] = fmt.Errorf("presence of user %v: %w", id,
+				domainerrors.ErrForbidden)
+			continue
+		}
+
+		allowed = append(allowed, id)
+	}
+
+	res, err := u.presenceRepository.FindPresences(ctx, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("find presences: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
+
+// PresenceChanged streams the presence changes of the current user, their
+// friends and the participants of their conversations.
+func (u *PresenceUsecase) PresenceChanged(ctx context.Context) (
+	<-chan *entity.Presence, error) {
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user is nil")
+	}
+
+	presences, err := u.presenceRepository.PresenceChanged(ctx, *user)
+	if err != nil {
+		return nil, fmt.Errorf("presence changed: %w", err)
+	}
+
+	return presences, nil
+}
+
+// keepAlive refreshes the connection until ctx is done, then forgets it.
+func (u *PresenceUsecase) keepAlive(ctx context.Context, conn *connection) {
+	ticker := time.NewTicker(presenceHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// skip error, the next heartbeat tries again
+			_ = u.presenceRepository.RefreshConnection(ctx, conn.userID, conn.id,
+				conn.getStatus())
+		case <-ctx.Done():
+			u.disconnect(context.Background(), conn)
+			return
+		}
+	}
+}
+
+func (u *PresenceUsecase) disconnect(ctx context.Context, conn *connection) {
+	before, err := u.presence(ctx, conn.userID)
+	if err != nil {
+		return
+	}
+
+	if err := u.presenceRepository.Disconnect(ctx, conn.userID, conn.id); err != nil {
+		return
+	}
+
+	after := u.publishChange(ctx, before)
+	if after != nil && after.Status == entity.PresenceStatusOffline {
+		// skip error, the sweeper releases the user otherwise
+		_, _ = u.presenceRepository.ReleaseExpiredUser(ctx, conn.userID)
+	}
+}
+
+// sweep turns offline the users whose connections expired without being
+// disconnected, e.g. because the process holding them died. Every process
+// sweeps, the one releasing a user publishes the change.
+func (u *PresenceUsecase) sweep() {
+	ticker := time.NewTicker(presenceHeartbeatInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+
+		ids, err := u.presenceRepository.FindExpiredUserIDs(ctx)
+		if err != nil || len(ids) == 0 {
+			continue
+		}
+
+		presences, err := u.presenceRepository.FindPresences(ctx, ids)
+		if err != nil {
+			continue
+		}
+
+		for _, id := range ids {
+			presence := presences[id]
+			if presence == nil || presence.Status != entity.PresenceStatusOffline {
+				continue
+			}
+
+			released, err := u.presenceRepository.ReleaseExpiredUser(ctx, id)
+			if err != nil || !released {
+				continue
+			}
+
+			// skip error when fanout presence
+			_ = u.presenceRepository.FanoutPresence(ctx, presence)
+		}
+	}
+}
+
+func (u *PresenceUsecase) presence(ctx context.Context,
+	userID entity.ID) (*entity.Presence, error) {
+	presences, err := u.presenceRepository.FindPresences(ctx, []entity.ID{userID})
+	if err != nil {
+		return nil, fmt.Errorf("find presences: %w", err)
+	}
+
+	return presences[userID], nil
+}
+
+// publishChange publishes the presence of the user when its status is no
+// longer the one of before, it returns the current presence or nil when it
+// could not be read.
+func (u *PresenceUsecase) publishChange(ctx context.Context,
+	before *entity.Presence) *entity.Presence {
+	after, err := u.presence(ctx, before.UserID)
+	if err != nil {
+		return nil
+	}
+
+	if after.Status != before.Status {
+		// skip error when fanout presence
+		_ = u.presenceRepository.FanoutPresence(ctx, after)
+	}
+
+	return after
+}
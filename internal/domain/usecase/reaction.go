@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+// AddReaction puts an emoji of the user on a message of one of their
+// conversations, reacting twice with the same emoji changes nothing.
+func (u *MessageUsecase) AddReaction(
+	ctx context.Context,
+	userID entity.ID,
+	messageID entity.ID,
+	emoji string,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("AddReaction: %w", err)
+	}
+
+	message, err := u.reactableMessage(ctx, userID, messageID, emoji)
+	if err != nil {
+		return fail(err)
+	}
+
+	added, err := u.messageRepository.AddReaction(ctx, messageID, userID, emoji)
+	if err != nil {
+		return fail(fmt.Errorf("add reaction: %w", err))
+	}
+
+	if added {
+		// skip error when fanout reaction event
+		_ = u.messageRepository.FanoutReactionEvent(ctx, &entity.ReactionEvent{
+			Message: message,
+			UserID:  userID,
+			Emoji:   emoji,
+			Added:   true,
+		})
+	}
+
+	return message, nil
+}
+
+// RemoveReaction takes an emoji of the user off a message, removing one that
+// is not there changes nothing.
+func (u *MessageUsecase) RemoveReaction(
+	ctx context.Context,
+	userID entity.ID,
+	messageID entity.ID,
+	emoji string,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("RemoveReaction: %w", err)
+	}
+
+	message, err := u.reactableMessage(ctx, userID, messageID, emoji)
+	if err != nil {
+		return fail(err)
+	}
+
+	removed, err := u.messageRepository.RemoveReaction(ctx, messageID, userID, emoji)
+	if err != nil {
+		return fail(fmt.Errorf("remove reaction: %w", err))
+	}
+
+	if removed {
+		// skip error when fanout reaction event
+		_ = u.messageRepository.FanoutReactionEvent(ctx, &entity.ReactionEvent{
+			Message: message,
+			UserID:  userID,
+			Emoji:   emoji,
+			Added:   false,
+		})
+	}
+
+	return message, nil
+}
+
+func (u *MessageUsecase) ReactionChanged(ctx context.Context,
+	conversationID *entity.ID) (<-chan *entity.ReactionEvent, error) {
+	user, err := u.subscriber(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.messageRepository.ReactionChanged(ctx, *user, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("reaction changed: %w", err)
+	}
+
+	return events, nil
+}
+
+// ReactionsOfMessages returns the reactions of each message grouped by emoji,
+// as seen by the current user.
+func (u *MessageUsecase) ReactionsOfMessages(ctx context.Context,
+	messageIDs []entity.ID) (map[entity.ID][]*entity.ReactionGroup, error) {
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user is nil")
+	}
+
+	allowed, keyErrors, err := u.authorizeMessagesPerKey(ctx, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize messages: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID][]*entity.ReactionGroup{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindReactionGroups(ctx, user.ID, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("find reaction groups: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
+
+// reactableMessage returns the message once checked that the user may react
+// to it with the emoji.
+func (u *MessageUsecase) reactableMessage(
+	ctx context.Context,
+	userID entity.ID,
+	messageID entity.ID,
+	emoji string,
+) (*entity.Message, error) {
+	if !entity.IsValidEmoji(emoji) {
+		return nil, fmt.Errorf("emoji %q: %w", emoji, domainerrors.ErrInvalid)
+	}
+
+	messages, err := u.messageRepository.FindMessagesByIDs(ctx, []entity.ID{messageID})
+	if err != nil {
+		return nil, fmt.Errorf("find messages: %w", err)
+	}
+
+	if len(messages) == 0 || messages[0].DeletedAt != nil {
+		return nil, fmt.Errorf("message %v: %w", messageID, domainerrors.ErrNotFound)
+	}
+
+	message := messages[0]
+
+	if err := authorizeParticipant(ctx, u.messageRepository, userID,
+		message.ConversationID); err != nil {
+		return nil, fmt.Errorf("authorize participant: %w", err)
+	}
+
+	return message, nil
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+// MarkConversationRead records that the user read the conversation up to the
+// message.
+func (u *MessageUsecase) MarkConversationRead(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	messageID entity.ID,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("MarkConversationRead: %w", err)
+	}
+
+	if err := authorizeParticipant(ctx, u.messageRepository, userID,
+		conversationID); err != nil {
+		return fail(fmt.Errorf("authorize participant: %w", err))
+	}
+
+	messages, err := u.messageRepository.FindMessagesByIDs(ctx, []entity.ID{messageID})
+	if err != nil {
+		return fail(fmt.Errorf("find messages: %w", err))
+	}
+
+	if len(messages) == 0 || messages[0].ConversationID != conversationID {
+		return fail(fmt.Errorf("message %v is not in conversation %v: %w",
+			messageID, conversationID, domainerrors.ErrInvalid))
+	}
+
+	if err := u.messageRepository.MarkConversationRead(ctx, conversationID, userID,
+		messageID); err != nil {
+		return fail(fmt.Errorf("mark conversation read: %w", err))
+	}
+
+	conversations, err := u.messageRepository.FindConversationsByIDs(ctx,
+		[]entity.ID{conversationID})
+	if err != nil {
+		return fail(fmt.Errorf("find conversations: %w", err))
+	}
+
+	if len(conversations) == 0 {
+		return fail(fmt.Errorf("conversation %v: %w", conversationID,
+			domainerrors.ErrNotFound))
+	}
+
+	return conversations[0], nil
+}
+
+// UnreadCountsOfConversations returns how many messages the current user has
+// not read in each conversation.
+func (u *MessageUsecase) UnreadCountsOfConversations(ctx context.Context,
+	conversationIDs []entity.ID) (map[entity.ID]int, error) {
+	user, err := u.userRepository.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user is nil")
+	}
+
+	allowed, keyErrors, err := authorizeParticipantPerKey(ctx, u.messageRepository,
+		user.ID, conversationIDs, conversationsOfThemselves(conversationIDs))
+	if err != nil {
+		return nil, fmt.Errorf("authorize participant: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID]int{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindUnreadCounts(ctx, user.ID, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("find unread counts: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
+
+func (u *MessageUsecase) ReadReceiptsInConversations(ctx context.Context,
+	conversationIDs []entity.ID) (map[entity.ID][]*entity.ReadReceipt, error) {
+	allowed, keyErrors, err := u.authorizeConversationsPerKey(ctx, conversationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("authorize conversations: %w", err)
+	}
+
+	if len(allowed) == 0 {
+		return map[entity.ID][]*entity.ReadReceipt{}, keyErrors.Err()
+	}
+
+	res, err := u.messageRepository.FindReadReceipts(ctx, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("find read receipts: %w", err)
+	}
+
+	return res, keyErrors.Err()
+}
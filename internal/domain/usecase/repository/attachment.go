@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type AttachmentRepository interface {
+	StoreContent(
+		ctx context.Context,
+		content []byte,
+		contentType string,
+	) (string, int64, error)
+	DiscardContent(
+		ctx context.Context,
+		key string,
+	) error
+	OpenContent(
+		ctx context.Context,
+		key string,
+	) (io.ReadCloser, error)
+	CreateAttachmentWithTransaction(
+		ctx context.Context,
+		attachment *entity.Attachment,
+	) (*entity.Attachment, error)
+	FindAttachment(
+		ctx context.Context,
+		attachmentID entity.ID,
+	) (*entity.Attachment, error)
+	FindAttachments(
+		ctx context.Context,
+		messageIDs []entity.ID,
+	) (map[entity.ID][]*entity.Attachment, error)
+	FindUnprocessedImages(
+		ctx context.Context,
+		postedBefore time.Time,
+	) ([]*entity.Attachment, error)
+	ProcessImage(
+		ctx context.Context,
+		attachment *entity.Attachment,
+	) (*entity.Attachment, error)
+}
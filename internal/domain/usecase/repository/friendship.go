@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type FriendshipRepository interface {
+	LockFriendshipsWithTransaction(
+		ctx context.Context,
+		userID entity.ID,
+		otherID entity.ID,
+	) error
+	FindFriendshipWithTransaction(
+		ctx context.Context,
+		userID entity.ID,
+		friendID entity.ID,
+	) (*entity.Friendship, error)
+	SaveFriendshipWithTransaction(
+		ctx context.Context,
+		userID entity.ID,
+		friendID entity.ID,
+		status entity.FriendshipStatus,
+	) (*entity.Friendship, error)
+	DeleteFriendshipWithTransaction(
+		ctx context.Context,
+		userID entity.ID,
+		friendID entity.ID,
+	) error
+	FindPendingFriendRequests(
+		ctx context.Context,
+		userID entity.ID,
+	) ([]*entity.FriendRequest, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type LinkPreviewRepository interface {
+	FindLinkPreviews(
+		ctx context.Context,
+		links []string,
+	) (map[string]*entity.LinkPreview, []string, error)
+	UnfurlLink(
+		ctx context.Context,
+		link string,
+	) (bool, error)
+}
@@ -35,6 +35,7 @@ type MessageRepository interface {
 		msgType entity.MessageType,
 		senderID entity.ID,
 		msg string,
+		parentID *entity.ID,
 	) (*entity.Message, error)
 	CreateMessageWithTransaction(
 		ctx context.Context,
@@ -42,6 +43,7 @@ type MessageRepository interface {
 		msgType entity.MessageType,
 		senderID entity.ID,
 		msg string,
+		parentID *entity.ID,
 	) (*entity.Message, error)
 	FindAllMessagesInConversations(
 		ctx context.Context,
@@ -50,16 +52,166 @@ type MessageRepository interface {
 	MessagePosted(
 		ctx context.Context,
 		user entity.User,
+		conversationID *entity.ID,
 	) (<-chan *entity.Message, error)
 	FanoutMessage(
 		ctx context.Context,
 		message *entity.Message,
-	)
+	) error
 	FindConversationIDsFromUserIDs(ctx context.Context,
 		inputs []entity.RelayQueryInput) (map[entity.ID]*entity.IDsConnection, error)
 	FindParticipantsInConversations(ctx context.Context,
-		conversationIDs []entity.ID) (map[entity.ID][]*entity.User, error)
+		conversationIDs []entity.ID) (map[entity.ID][]*entity.Participant, error)
+	FindConversationIDsByParticipant(ctx context.Context, userID entity.ID,
+		conversationIDs []entity.ID) ([]entity.ID, error)
 	FindMessagesInConversations(ctx context.Context,
 		inputs []entity.RelayQueryInput,
 	) (map[entity.ID]*entity.ConversationMessagesConnection, error)
+	FindRepliesOfMessages(ctx context.Context,
+		inputs []entity.RelayQueryInput,
+	) (map[entity.ID]*entity.ConversationMessagesConnection, error)
+	MessageUpdated(
+		ctx context.Context,
+		user entity.User,
+		conversationID *entity.ID,
+	) (<-chan *entity.Message, error)
+	FanoutMessageUpdate(
+		ctx context.Context,
+		message *entity.Message,
+	) error
+	FindMessagesByIDs(
+		ctx context.Context,
+		messageIDs []entity.ID,
+	) ([]*entity.Message, error)
+	FindMessageWithTransaction(
+		ctx context.Context,
+		messageID entity.ID,
+	) (*entity.Message, error)
+	EditMessageWithTransaction(
+		ctx context.Context,
+		messageID entity.ID,
+		content string,
+	) (*entity.Message, error)
+	DeleteMessageWithTransaction(
+		ctx context.Context,
+		messageID entity.ID,
+	) (*entity.Message, error)
+	MessageDeleted(
+		ctx context.Context,
+		user entity.User,
+		conversationID *entity.ID,
+	) (<-chan *entity.Message, error)
+	FanoutMessageDeletion(
+		ctx context.Context,
+		message *entity.Message,
+	) error
+	AddReaction(
+		ctx context.Context,
+		messageID entity.ID,
+		userID entity.ID,
+		emoji string,
+	) (bool, error)
+	RemoveReaction(
+		ctx context.Context,
+		messageID entity.ID,
+		userID entity.ID,
+		emoji string,
+	) (bool, error)
+	FindReactionGroups(
+		ctx context.Context,
+		viewerID entity.ID,
+		messageIDs []entity.ID,
+	) (map[entity.ID][]*entity.ReactionGroup, error)
+	ReactionChanged(
+		ctx context.Context,
+		user entity.User,
+		conversationID *entity.ID,
+	) (<-chan *entity.ReactionEvent, error)
+	FanoutReactionEvent(
+		ctx context.Context,
+		event *entity.ReactionEvent,
+	) error
+	MarkConversationRead(
+		ctx context.Context,
+		conversationID entity.ID,
+		userID entity.ID,
+		messageID entity.ID,
+	) error
+	FindUnreadCounts(
+		ctx context.Context,
+		userID entity.ID,
+		conversationIDs []entity.ID,
+	) (map[entity.ID]int, error)
+	FindReadReceipts(
+		ctx context.Context,
+		conversationIDs []entity.ID,
+	) (map[entity.ID][]*entity.ReadReceipt, error)
+	FindCachedParticipantIDs(
+		ctx context.Context,
+		conversationID entity.ID,
+	) ([]entity.ID, error)
+	RenewParticipantIDsGeneration(
+		ctx context.Context,
+		conversationID entity.ID,
+	) error
+	PublishTyping(
+		ctx context.Context,
+		typing *entity.TypingEvent,
+		participantIDs []entity.ID,
+	) error
+	TypingChanged(
+		ctx context.Context,
+		user entity.User,
+		conversationID entity.ID,
+	) (<-chan *entity.TypingEvent, error)
+	FindMessageRevisions(
+		ctx context.Context,
+		messageIDs []entity.ID,
+	) (map[entity.ID][]*entity.MessageRevision, error)
+	LockConversationWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+	) (*entity.Conversation, error)
+	FindParticipantsWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+	) ([]*entity.Participant, error)
+	SetParticipantRoleWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+		userID entity.ID,
+		role entity.ParticipantRole,
+	) error
+	RenameConversationWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+		title string,
+	) error
+	AddParticipantsWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+		userIDs []entity.ID,
+	) ([]entity.ID, error)
+	RemoveParticipantWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+		userID entity.ID,
+	) (bool, error)
+	DeleteConversationWithTransaction(
+		ctx context.Context,
+		conversationID entity.ID,
+	) error
+	ConversationUpdated(
+		ctx context.Context,
+		user entity.User,
+	) (<-chan *entity.ConversationEvent, error)
+	FanoutConversationEvent(
+		ctx context.Context,
+		event *entity.ConversationEvent,
+	) error
+	SearchMessages(
+		ctx context.Context,
+		viewerID entity.ID,
+		query entity.MessageSearchQuery,
+	) (*entity.MessageSearchConnection, error)
 }
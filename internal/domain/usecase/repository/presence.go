@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type PresenceRepository interface {
+	Connect(
+		ctx context.Context,
+		userID entity.ID,
+		status entity.PresenceStatus,
+	) (string, error)
+	RefreshConnection(
+		ctx context.Context,
+		userID entity.ID,
+		connectionID string,
+		status entity.PresenceStatus,
+	) error
+	Disconnect(
+		ctx context.Context,
+		userID entity.ID,
+		connectionID string,
+	) error
+	FindPresences(
+		ctx context.Context,
+		userIDs []entity.ID,
+	) (map[entity.ID]*entity.Presence, error)
+	FindUserIDsSeenBy(
+		ctx context.Context,
+		viewerID entity.ID,
+		userIDs []entity.ID,
+	) ([]entity.ID, error)
+	FindExpiredUserIDs(ctx context.Context) ([]entity.ID, error)
+	ReleaseExpiredUser(ctx context.Context, userID entity.ID) (bool, error)
+	PresenceChanged(
+		ctx context.Context,
+		user entity.User,
+	) (<-chan *entity.Presence, error)
+	FanoutPresence(
+		ctx context.Context,
+		presence *entity.Presence,
+	) error
+}
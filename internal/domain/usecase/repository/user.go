@@ -12,9 +12,8 @@ type UserRepository interface {
 	GetUserFromContext(ctx context.Context) (*entity.User, error)
 	GetAuthTokenFromContext(ctx context.Context) (*entity.AuthToken, error)
 	UserJoined(ctx context.Context, user entity.User) (<-chan *entity.User, error)
-	FindFriends(ctx context.Context, first int, after entity.ID,
-		sortBy entity.FriendsSortByType, sortOrder entity.SortOrderType,
-	) (*entity.FriendsConnection, error)
+	FindFriends(ctx context.Context, userID entity.ID,
+		input entity.ListQueryInput) (*entity.FriendsConnection, error)
 	FindUsers(ctx context.Context, userIDs []entity.ID) ([]*entity.User, error)
 	GetFriendIDsFromUserIDs(ctx context.Context,
 		inputs []entity.RelayQueryInput) (map[entity.ID]*entity.IDsConnection,
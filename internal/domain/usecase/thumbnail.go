@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+const (
+	// imageWorkers is how many images are processed at once.
+	imageWorkers = 2
+	// imageQueueSize bounds the images waiting to be processed, the ones
+	// which do not fit are left to the sweeper.
+	imageQueueSize = 64
+	// imageSweepInterval is how often images left unprocessed, e.g. by a
+	// process which died, are looked for. They are taken over once older than
+	// the interval.
+	imageSweepInterval = time.Minute
+)
+
+// startImagePipeline processes the posted images in the background.
+func (u *MessageUsecase) startImagePipeline() {
+	for i := 0; i < imageWorkers; i++ {
+		go func() {
+			for attachment := range u.images {
+				u.processImage(context.Background(), attachment)
+			}
+		}()
+	}
+
+	go u.sweepImages()
+}
+
+// enqueueImage has the image processed unless the queue is full.
+func (u *MessageUsecase) enqueueImage(attachment *entity.Attachment) {
+	select {
+	case u.images <- attachment:
+	default:
+	}
+}
+
+// processImage processes the image and publishes its message as updated,
+// clients then get the thumbnail.
+func (u *MessageUsecase) processImage(ctx context.Context, attachment *entity.Attachment) {
+	processed, err := u.attachmentRepository.ProcessImage(ctx, attachment)
+	if err != nil || processed == nil {
+		return
+	}
+
+	messages, err := u.messageRepository.FindMessagesByIDs(ctx,
+		[]entity.ID{processed.MessageID})
+	if err != nil {
+		return
+	}
+
+	for _, message := range messages {
+		// skip error when fanout message update
+		_ = u.messageRepository.FanoutMessageUpdate(ctx, message)
+	}
+}
+
+func (u *MessageUsecase) sweepImages() {
+	ticker := time.NewTicker(imageSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		attachments, err := u.attachmentRepository.FindUnprocessedImages(
+			context.Background(), time.Now().Add(-imageSweepInterval))
+		if err != nil {
+			continue
+		}
+
+		for _, attachment := range attachments {
+			u.enqueueImage(attachment)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+// StartTyping tells the participants of the conversation that the user is
+// typing, the indicator expires unless started again.
+func (u *MessageUsecase) StartTyping(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+) (*entity.TypingEvent, error) {
+	typing, err := u.publishTyping(ctx, userID, conversationID, true)
+	if err != nil {
+		return nil, fmt.Errorf("StartTyping: %w", err)
+	}
+
+	return typing, nil
+}
+
+func (u *MessageUsecase) StopTyping(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+) (*entity.TypingEvent, error) {
+	typing, err := u.publishTyping(ctx, userID, conversationID, false)
+	if err != nil {
+		return nil, fmt.Errorf("StopTyping: %w", err)
+	}
+
+	return typing, nil
+}
+
+func (u *MessageUsecase) TypingChanged(ctx context.Context,
+	conversationID entity.ID) (<-chan *entity.TypingEvent, error) {
+	user, err := u.subscriber(ctx, &conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := u.messageRepository.TypingChanged(ctx, *user, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("typing changed: %w", err)
+	}
+
+	return events, nil
+}
+
+func (u *MessageUsecase) publishTyping(
+	ctx context.Context,
+	userID entity.ID,
+	conversationID entity.ID,
+	isTyping bool,
+) (*entity.TypingEvent, error) {
+	// typing is sent on every keystroke, the participants come from the
+	// cache rather than from authorizeParticipant
+	participantIDs, err := u.messageRepository.FindCachedParticipantIDs(ctx,
+		conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("find cached participant ids: %w", err)
+	}
+
+	if !containsID(participantIDs, userID) {
+		return nil, fmt.Errorf("user %v is not a participant of conversation %v: %w",
+			userID, conversationID, domainerrors.ErrForbidden)
+	}
+
+	typing := &entity.TypingEvent{
+		ConversationID: conversationID,
+		UserID:         userID,
+		Typing:         isTyping,
+	}
+
+	if err := u.messageRepository.PublishTyping(ctx, typing, participantIDs); err != nil {
+		return nil, fmt.Errorf("publish typing: %w", err)
+	}
+
+	return typing, nil
+}
+
+func containsID(ids []entity.ID, id entity.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -35,6 +35,15 @@ func (u *UserUsecase) GetUserFromContext(ctx context.Context) (*entity.User, err
 	return user, nil
 }
 
+func (u *UserUsecase) GetAuthTokenFromContext(ctx context.Context) (*entity.AuthToken, error) {
+	token, err := u.userRepository.GetAuthTokenFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get auth token from context: %w", err)
+	}
+
+	return token, nil
+}
+
 func (u *UserUsecase) Login(ctx context.Context) (*entity.User, error) {
 	token, err := u.userRepository.GetAuthTokenFromContext(ctx)
 	if err != nil {
@@ -69,12 +78,14 @@ func (u *UserUsecase) Login(ctx context.Context) (*entity.User, error) {
 
 func (u *UserUsecase) Friends(
 	ctx context.Context,
-	first int,
-	after entity.ID,
-	sortBy entity.FriendsSortByType,
-	sortOrder entity.SortOrderType,
+	input entity.ListQueryInput,
 ) (*entity.FriendsConnection, error) {
-	users, err := u.userRepository.FindFriends(ctx, first, after, sortBy, sortOrder)
+	user, err := u.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get user from context: %w", err)
+	}
+
+	users, err := u.userRepository.FindFriends(ctx, user.ID, input)
 	if err != nil {
 		return nil, fmt.Errorf("find all: %w", err)
 	}
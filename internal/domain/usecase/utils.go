@@ -15,3 +15,24 @@ func errorHandlerWithTransaction(ctx context.Context,
 
 	return err
 }
+
+// inTransaction runs fn with a transaction begun in its context. The
+// transaction is committed when fn succeeds and rolled back otherwise.
+func inTransaction(ctx context.Context, transactor repository.Transactor,
+	fn func(txCtx context.Context) error) error {
+	txCtx, err := transactor.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(txCtx); err != nil {
+		return errorHandlerWithTransaction(txCtx, transactor, err)
+	}
+
+	if err := transactor.Commit(txCtx); err != nil {
+		return errorHandlerWithTransaction(txCtx, transactor,
+			fmt.Errorf("commit transaction: %w", err))
+	}
+
+	return nil
+}
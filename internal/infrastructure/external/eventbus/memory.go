@@ -0,0 +1,38 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryEventBus delivers events inside the current process only, it is
+// enough when a single instance is running.
+type MemoryEventBus struct {
+	handlers map[string][]func(payload []byte)
+	mutex    sync.RWMutex
+}
+
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{
+		handlers: map[string][]func(payload []byte){},
+		mutex:    sync.RWMutex{},
+	}
+}
+
+func (b *MemoryEventBus) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, handler := range b.handlers[topic] {
+		handler(payload)
+	}
+
+	return nil
+}
+
+func (b *MemoryEventBus) Subscribe(topic string, handler func(payload []byte)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.handlers[topic] = append(b.handlers[topic], handler)
+}
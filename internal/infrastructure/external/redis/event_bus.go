@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis"
+)
+
+const eventBusChannelPrefix = "chat:events:"
+
+// RedisEventBus delivers events to every instance connected to the same redis
+// through pub/sub.
+type RedisEventBus struct {
+	client  *RedisClient
+	pubsubs []*redis.PubSub
+	mutex   sync.Mutex
+}
+
+func NewRedisEventBus(client *RedisClient) (*RedisEventBus, func()) {
+	bus := &RedisEventBus{
+		client: client,
+		mutex:  sync.Mutex{},
+	}
+
+	return bus, bus.close
+}
+
+func (b *RedisEventBus) Publish(ctx context.Context, topic string, payload []byte) error {
+	if err := b.client.client.Publish(eventBusChannelPrefix+topic, payload).Err(); err != nil {
+		return fmt.Errorf("redis publish: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe starts listening on the topic, the underlying connection is
+// re-established by the redis client when it drops.
+func (b *RedisEventBus) Subscribe(topic string, handler func(payload []byte)) {
+	pubsub := b.client.client.Subscribe(eventBusChannelPrefix + topic)
+
+	b.mutex.Lock()
+	b.pubsubs = append(b.pubsubs, pubsub)
+	b.mutex.Unlock()
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+}
+
+func (b *RedisEventBus) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, pubsub := range b.pubsubs {
+		_ = pubsub.Close()
+	}
+
+	b.pubsubs = nil
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+const eventTimeout = 5 * time.Second
+
+func newTestEventBus(t *testing.T, server *miniredis.Miniredis) *RedisEventBus {
+	t.Helper()
+
+	bus, cleanup := NewRedisEventBus(NewRedisClient(RedisClientOption{Addr: server.Addr()}))
+	t.Cleanup(cleanup)
+
+	return bus
+}
+
+// subscribe collects the payloads of the topic, the subscription is
+// confirmed asynchronously so the callers publish until something arrives.
+func subscribe(bus *RedisEventBus, topic string) <-chan string {
+	payloads := make(chan string, 16)
+	bus.Subscribe(topic, func(payload []byte) {
+		payloads <- string(payload)
+	})
+
+	return payloads
+}
+
+func publishUntilReceived(t *testing.T, bus *RedisEventBus, topic, payload string,
+	payloads <-chan string) {
+	t.Helper()
+
+	deadline := time.After(eventTimeout)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		// errors are expected while the connection is being re-established
+		_ = bus.Publish(context.Background(), topic, []byte(payload))
+
+		select {
+		case got := <-payloads:
+			// retried publications of an earlier payload may still arrive
+			if got == payload {
+				return
+			}
+		case <-ticker.C:
+		case <-deadline:
+			t.Fatalf("payload %q not received within %v", payload, eventTimeout)
+		}
+	}
+}
+
+func TestRedisEventBus_PublishAcrossInstances(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer server.Close()
+
+	publisher := newTestEventBus(t, server)
+	subscriber := newTestEventBus(t, server)
+
+	posted := subscribe(subscriber, "message_posted")
+	joined := subscribe(subscriber, "user_joined")
+
+	publishUntilReceived(t, publisher, "message_posted", "hello", posted)
+	publishUntilReceived(t, publisher, "user_joined", "alice", joined)
+
+	timeout := time.After(100 * time.Millisecond)
+	for {
+		select {
+		case got := <-posted:
+			if got != "hello" {
+				t.Fatalf("got payload %q of another topic", got)
+			}
+		case got := <-joined:
+			if got != "alice" {
+				t.Fatalf("got payload %q of another topic", got)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func TestRedisEventBus_ResubscribesAfterRestart(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis: %v", err)
+	}
+	defer server.Close()
+
+	publisher := newTestEventBus(t, server)
+	subscriber := newTestEventBus(t, server)
+
+	payloads := subscribe(subscriber, "message_posted")
+	publishUntilReceived(t, publisher, "message_posted", "before", payloads)
+
+	// a restart drops every connection along with its subscriptions
+	server.Close()
+	if err := server.Restart(); err != nil {
+		t.Fatalf("restart miniredis: %v", err)
+	}
+
+	publishUntilReceived(t, publisher, "message_posted", "after", payloads)
+}
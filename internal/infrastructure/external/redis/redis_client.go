@@ -2,6 +2,8 @@ package redis
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/go-redis/redis"
 )
@@ -58,3 +60,129 @@ func (c *RedisClient) SMembers(key string) ([]string, error) {
 
 	return res, nil
 }
+
+// HSetWithTTL sets the field of the hash and lets the whole hash expire after
+// ttl unless set again.
+func (c *RedisClient) HSetWithTTL(key, field string, value []byte, ttl time.Duration) error {
+	_, err := c.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(key, field, value)
+		pipe.Expire(key, ttl)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis hset: %w", err)
+	}
+
+	return nil
+}
+
+func (c *RedisClient) HDel(key, field string) error {
+	if err := c.client.HDel(key, field).Err(); err != nil {
+		return fmt.Errorf("redis hdel: %w", err)
+	}
+
+	return nil
+}
+
+// HGetAll reads every given hash in one round trip, a missing hash is empty.
+func (c *RedisClient) HGetAll(keys ...string) ([]map[string]string, error) {
+	cmds := make([]*redis.StringStringMapCmd, 0, len(keys))
+
+	_, err := c.client.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.HGetAll(key))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("redis hgetall: %w", err)
+	}
+
+	res := make([]map[string]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		res = append(res, cmd.Val())
+	}
+
+	return res, nil
+}
+
+func (c *RedisClient) Set(key string, value []byte) error {
+	if err := c.client.Set(key, value, 0).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+
+	return nil
+}
+
+func (c *RedisClient) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
+
+	return nil
+}
+
+// SetNX sets the key unless it exists, it reports whether it was set.
+func (c *RedisClient) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	set, err := c.client.SetNX(key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis setnx: %w", err)
+	}
+
+	return set, nil
+}
+
+// MGet reads the given keys, the value of a missing key is nil.
+func (c *RedisClient) MGet(keys ...string) ([]*string, error) {
+	values, err := c.client.MGet(keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis mget: %w", err)
+	}
+
+	res := make([]*string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			res = append(res, nil)
+			continue
+		}
+
+		res = append(res, &s)
+	}
+
+	return res, nil
+}
+
+func (c *RedisClient) ZAdd(key string, score float64, member string) error {
+	if err := c.client.ZAdd(key, redis.Z{Score: score, Member: member}).Err(); err != nil {
+		return fmt.Errorf("redis zadd: %w", err)
+	}
+
+	return nil
+}
+
+// ZRangeByScore returns the members of the sorted set scored up to max.
+func (c *RedisClient) ZRangeByScore(key string, max float64) ([]string, error) {
+	res, err := c.client.ZRangeByScore(key, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatFloat(max, 'f', -1, 64),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis zrangebyscore: %w", err)
+	}
+
+	return res, nil
+}
+
+// ZRem removes the member of the sorted set, it reports whether the member
+// was there.
+func (c *RedisClient) ZRem(key, member string) (bool, error) {
+	removed, err := c.client.ZRem(key, member).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis zrem: %w", err)
+	}
+
+	return removed > 0, nil
+}
@@ -0,0 +1,234 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+const (
+	fetchTimeout = 5 * time.Second
+	dialTimeout  = 2 * time.Second
+	maxRedirects = 3
+	// maxPageSize bounds what is read of a page, the metadata is expected in
+	// its head.
+	maxPageSize = 512 << 10
+	userAgent   = "ChatLinkPreview/1.0"
+)
+
+// ErrForbiddenAddress is returned for the pages served from an address
+// which is not public, e.g. the loopback or a private network.
+var ErrForbiddenAddress = errors.New("forbidden address")
+
+// Fetcher reads the previews of the pages on the web. Every connection is
+// checked against the address it is made to, so that neither the links nor
+// their redirects nor their DNS records can make it reach the internal
+// network.
+type Fetcher struct {
+	client   *http.Client
+	checkURL func(u *url.URL) error
+}
+
+func NewFetcher() *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return checkAddress(address)
+		},
+	}
+
+	f := &Fetcher{
+		client: &http.Client{
+			Timeout: fetchTimeout,
+			Transport: &http.Transport{
+				// a proxy would connect on behalf of the fetcher, unchecked
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   dialTimeout,
+				ResponseHeaderTimeout: fetchTimeout,
+				MaxIdleConns:          16,
+				IdleConnTimeout:       time.Minute,
+			},
+		},
+		checkURL: checkURL,
+	}
+	f.client.CheckRedirect = f.checkRedirect
+
+	return f
+}
+
+// NewFetcherWithClient returns a fetcher using a copy of the given client
+// with the redirect policy of the fetcher, it is meant for tests against a
+// local server which the default client refuses.
+func NewFetcherWithClient(client *http.Client) *Fetcher {
+	c := *client
+	f := &Fetcher{client: &c, checkURL: checkScheme}
+	f.client.CheckRedirect = f.checkRedirect
+
+	return f
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*entity.LinkPreview, error) {
+	fail := func(err error) (*entity.LinkPreview, error) {
+		return nil, fmt.Errorf("fetch %v: %w", rawURL, err)
+	}
+
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := f.checkURL(pageURL); err != nil {
+		return fail(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return fail(err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil
+	}
+
+	page, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return fail(err)
+	}
+
+	// relative image links are relative to the page after its redirects
+	return parsePreview(rawURL, resp.Request.URL, string(page)), nil
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %v redirects", maxRedirects)
+	}
+
+	return f.checkURL(req.URL)
+}
+
+// checkScheme refuses the links which are not web pages.
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q: %w", u.Scheme, ErrForbiddenAddress)
+	}
+
+	return nil
+}
+
+// checkURL refuses the links which are not web pages on the standard ports.
+func checkURL(u *url.URL) error {
+	if err := checkScheme(u); err != nil {
+		return err
+	}
+
+	if u.User != nil {
+		return fmt.Errorf("credentials in link: %w", ErrForbiddenAddress)
+	}
+
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		return fmt.Errorf("port %v: %w", port, ErrForbiddenAddress)
+	}
+
+	return nil
+}
+
+// checkAddress refuses the connections to the addresses which are not
+// public.
+func checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("address %v: %w", address, ErrForbiddenAddress)
+	}
+
+	if !isPublicIP(ip) {
+		return fmt.Errorf("address %v: %w", ip, ErrForbiddenAddress)
+	}
+
+	return nil
+}
+
+// nonPublicNetworks are the special purpose ranges besides the loopback,
+// link local, multicast and unspecified ones.
+var nonPublicNetworks = parseNetworks(
+	"0.0.0.0/8",       // this network
+	"10.0.0.0/8",      // private
+	"100.64.0.0/10",   // carrier grade NAT
+	"172.16.0.0/12",   // private
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"192.168.0.0/16",  // private
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved
+	"64:ff9b::/96",    // IPv4 translation, could map to a private address
+	"100::/64",        // discard
+	"2001:db8::/32",   // documentation
+	"fc00::/7",        // unique local
+)
+
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		// IPv4 mapped IPv6 addresses are checked as IPv4
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks
+}
@@ -0,0 +1,123 @@
+package unfurl
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+// maxFieldLength bounds in bytes the texts kept from a page.
+const maxFieldLength = 500
+
+var (
+	metaPattern      = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z:_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	// bodyPattern marks the end of the head, metadata after it is ignored
+	bodyPattern = regexp.MustCompile(`(?i)<body[\s>]`)
+)
+
+// parsePreview reads the OpenGraph tags of the page, then the Twitter card
+// ones, then the standard ones. It returns nil when the page has no title.
+func parsePreview(link string, pageURL *url.URL, page string) *entity.LinkPreview {
+	if loc := bodyPattern.FindStringIndex(page); loc != nil {
+		page = page[:loc[0]]
+	}
+
+	meta := make(map[string]string)
+
+	for _, tag := range metaPattern.FindAllString(page, -1) {
+		attributes := make(map[string]string)
+		for _, m := range attributePattern.FindAllStringSubmatch(tag, -1) {
+			attributes[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+		}
+
+		name := attributes["property"]
+		if name == "" {
+			name = attributes["name"]
+		}
+
+		name = strings.ToLower(name)
+		if _, ok := meta[name]; !ok && name != "" {
+			meta[name] = attributes["content"]
+		}
+	}
+
+	first := func(names ...string) *string {
+		for _, name := range names {
+			if value := clean(meta[name]); value != "" {
+				return &value
+			}
+		}
+
+		return nil
+	}
+
+	preview := &entity.LinkPreview{
+		URL:         link,
+		SiteName:    first("og:site_name", "application-name"),
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		ImageURL:    resolveImage(pageURL, first("og:image", "og:image:url", "twitter:image", "twitter:image:src")),
+	}
+
+	if preview.Title == nil {
+		if m := titlePattern.FindStringSubmatch(page); m != nil {
+			if title := clean(m[1]); title != "" {
+				preview.Title = &title
+			}
+		}
+	}
+
+	if preview.Title == nil {
+		return nil
+	}
+
+	return preview
+}
+
+// resolveImage makes the image link absolute, links which are not on the web
+// are dropped.
+func resolveImage(pageURL *url.URL, image *string) *string {
+	if image == nil {
+		return nil
+	}
+
+	ref, err := url.Parse(*image)
+	if err != nil {
+		return nil
+	}
+
+	resolved := pageURL.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return nil
+	}
+
+	s := resolved.String()
+
+	return &s
+}
+
+// clean unescapes the text and collapses its spaces, it is cut to
+// maxFieldLength.
+func clean(s string) string {
+	s = strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "")
+	}
+
+	if len(s) <= maxFieldLength {
+		return s
+	}
+
+	s = s[:maxFieldLength]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s + "…"
+}
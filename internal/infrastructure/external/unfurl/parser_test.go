@@ -0,0 +1,136 @@
+package unfurl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParsePreview(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/articles/1")
+
+	tests := []struct {
+		name        string
+		page        string
+		wantNil     bool
+		title       string
+		description string
+		siteName    string
+		image       string
+	}{
+		{
+			name: "opengraph tags come first",
+			page: `<html><head>
+				<title>Page title</title>
+				<meta name="description" content="Standard description">
+				<meta name="twitter:title" content="Card title">
+				<meta property="og:title" content="Graph title">
+				<meta property="og:description" content='Graph &amp; description'>
+				<meta property="og:site_name" content="Example">
+				<meta property="og:image" content="/images/cover.png">
+			</head><body></body></html>`,
+			title:       "Graph title",
+			description: "Graph & description",
+			siteName:    "Example",
+			image:       "https://example.com/images/cover.png",
+		},
+		{
+			name: "twitter card tags come before the standard ones",
+			page: `<head>
+				<meta name="description" content="Standard description">
+				<meta name="twitter:description" content="Card description">
+				<meta name="twitter:title" content="Card title">
+				<meta name="twitter:image" content="https://cdn.example.com/card.png">
+			</head>`,
+			title:       "Card title",
+			description: "Card description",
+			image:       "https://cdn.example.com/card.png",
+		},
+		{
+			name: "title element and spaces are collapsed",
+			page: `<HEAD><TITLE>
+				A   title
+				on lines
+			</TITLE><META CONTENT="Described" NAME="Description"></HEAD>`,
+			title:       "A title on lines",
+			description: "Described",
+		},
+		{
+			name:    "metadata of the body is ignored",
+			page:    `<head></head><body><title>Not a title</title><meta property="og:title" content="Nor this"></body>`,
+			wantNil: true,
+		},
+		{
+			name:  "images which are not on the web are dropped",
+			page:  `<head><title>Title</title><meta property="og:image" content="javascript:alert(1)"></head>`,
+			title: "Title",
+		},
+		{
+			name:  "first occurrence of a tag wins",
+			page:  `<head><meta property="og:title" content="First"><meta property="og:title" content="Second"></head>`,
+			title: "First",
+		},
+		{
+			name:    "page without title",
+			page:    `<head><meta property="og:description" content="Description only"></head>`,
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview := parsePreview("https://example.com/a", pageURL, tt.page)
+			if tt.wantNil {
+				if preview != nil {
+					t.Fatalf("got preview %+v, want nil", preview)
+				}
+				return
+			}
+
+			if preview == nil {
+				t.Fatal("got nil preview")
+			}
+
+			if preview.URL != "https://example.com/a" {
+				t.Errorf("got url %q, want the link", preview.URL)
+			}
+
+			assertField(t, "title", preview.Title, tt.title)
+			assertField(t, "description", preview.Description, tt.description)
+			assertField(t, "site name", preview.SiteName, tt.siteName)
+			assertField(t, "image", preview.ImageURL, tt.image)
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	long := strings.Repeat("é", maxFieldLength)
+
+	got := clean(long)
+	if !strings.HasSuffix(got, "…") {
+		t.Fatalf("got %q, want an ellipsis", got)
+	}
+
+	if n := len(strings.TrimSuffix(got, "…")); n > maxFieldLength {
+		t.Errorf("got %v bytes, want at most %v", n, maxFieldLength)
+	}
+
+	if got := clean("a\xffb"); got != "ab" {
+		t.Errorf("got %q, want invalid UTF-8 removed", got)
+	}
+}
+
+// assertField checks an optional field, an empty want means the field is
+// absent.
+func assertField(t *testing.T, name string, got *string, want string) {
+	t.Helper()
+
+	switch {
+	case want == "" && got != nil:
+		t.Errorf("got %v %q, want none", name, *got)
+	case want != "" && got == nil:
+		t.Errorf("got no %v, want %q", name, want)
+	case want != "" && *got != want:
+		t.Errorf("got %v %q, want %q", name, *got, want)
+	}
+}
@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/imaging"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// attachmentKeyPrefix namespaces the attachment contents in the blob store.
+	attachmentKeyPrefix = "attachments/"
+	// thumbnailKeyPrefix namespaces the thumbnails of the images.
+	thumbnailKeyPrefix = "thumbnails/"
+	// unprocessedImagesLimit bounds the images returned at once to be processed.
+	unprocessedImagesLimit = 100
+)
+
+// attachmentColumns are the columns scanned by scanAttachment, a for the
+// attachments and m for their messages.
+const attachmentColumns = "a.id, a.message_id, m.conversation_id, a.uploader_id, a.file_name, " +
+	"a.content_type, a.size, a.storage_key, a.width, a.height, a.thumbnail_key, " +
+	"a.thumbnail_content_type, a.processed_at, a.created_at"
+
+type AttachmentRepository struct {
+	blobStore    external.BlobStore
+	dbTransactor external.Transactor
+	db           *sql.DB
+}
+
+func NewAttachmentRepository(
+	blobStore external.BlobStore,
+	dbTransactor external.Transactor,
+	db *sql.DB,
+) *AttachmentRepository {
+	return &AttachmentRepository{
+		blobStore:    blobStore,
+		dbTransactor: dbTransactor,
+		db:           db,
+	}
+}
+
+func scanAttachment(row rowScanner) (*entity.Attachment, error) {
+	var attachment entity.Attachment
+	if err := row.Scan(
+		&attachment.ID,
+		&attachment.MessageID,
+		&attachment.ConversationID,
+		&attachment.UploaderID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.Width,
+		&attachment.Height,
+		&attachment.ThumbnailKey,
+		&attachment.ThumbnailContentType,
+		&attachment.ProcessedAt,
+		&attachment.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// StoreContent puts the content in the blob store, images without their
+// location metadata, and returns the key and the size it is stored with.
+func (r *AttachmentRepository) StoreContent(
+	ctx context.Context,
+	content []byte,
+	contentType string,
+) (string, int64, error) {
+	fail := func(err error) (string, int64, error) {
+		return "", 0, fmt.Errorf("StoreContent: %w", err)
+	}
+
+	stripped, err := imaging.StripMetadata(content, contentType)
+	if err != nil {
+		return fail(err)
+	}
+
+	key := attachmentKeyPrefix + ksuid.New().String()
+
+	if err := r.blobStore.Put(ctx, key, bytes.NewReader(stripped), int64(len(stripped)),
+		contentType); err != nil {
+		return fail(err)
+	}
+
+	return key, int64(len(stripped)), nil
+}
+
+// DiscardContent removes a content which ended up attached to no message.
+func (r *AttachmentRepository) DiscardContent(ctx context.Context, key string) error {
+	if err := r.blobStore.Delete(ctx, key); err != nil {
+		return fmt.Errorf("DiscardContent: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) OpenContent(
+	ctx context.Context,
+	key string,
+) (io.ReadCloser, error) {
+	content, err := r.blobStore.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("OpenContent: %w", err)
+	}
+
+	return content, nil
+}
+
+func (r *AttachmentRepository) CreateAttachmentWithTransaction(
+	ctx context.Context,
+	attachment *entity.Attachment,
+) (*entity.Attachment, error) {
+	fail := func(err error) (*entity.Attachment, error) {
+		return nil, fmt.Errorf("CreateAttachmentWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	// only images are left to be processed
+	created, err := scanAttachment(tx.QueryRowContext(
+		ctx,
+		`WITH a AS (
+			INSERT INTO attachments(message_id, uploader_id, file_name, content_type, size, storage_key,
+				processed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $4::TEXT = ANY($7::TEXT[]) THEN NULL ELSE NOW() END)
+			RETURNING *
+		)
+		SELECT `+attachmentColumns+`
+		FROM a
+		INNER JOIN messages AS m ON m.id = a.message_id`,
+		attachment.MessageID, attachment.UploaderID, attachment.FileName,
+		attachment.ContentType, attachment.Size, attachment.StorageKey,
+		pq.Array(entity.ImageContentTypes()),
+	))
+	if err != nil {
+		return fail(err)
+	}
+
+	return created, nil
+}
+
+// FindAttachment returns the attachment unless its message was deleted.
+func (r *AttachmentRepository) FindAttachment(
+	ctx context.Context,
+	attachmentID entity.ID,
+) (*entity.Attachment, error) {
+	fail := func(err error) (*entity.Attachment, error) {
+		return nil, fmt.Errorf("FindAttachment: %w", err)
+	}
+
+	attachment, err := scanAttachment(r.db.QueryRowContext(
+		ctx,
+		`SELECT `+attachmentColumns+`
+		 FROM attachments AS a
+		 INNER JOIN messages AS m ON m.id = a.message_id
+		 WHERE a.id = $1 AND m.deleted_at IS NULL`,
+		attachmentID,
+	))
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fail(fmt.Errorf("attachment %v: %w", attachmentID, domainerrors.ErrNotFound))
+	case err != nil:
+		return fail(err)
+	default:
+		return attachment, nil
+	}
+}
+
+// FindAttachments returns the attachments of each message in upload order.
+// Deleted messages have none.
+func (r *AttachmentRepository) FindAttachments(
+	ctx context.Context,
+	messageIDs []entity.ID,
+) (map[entity.ID][]*entity.Attachment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+attachmentColumns+`
+		 FROM attachments AS a
+		 INNER JOIN messages AS m ON m.id = a.message_id
+		 WHERE a.message_id = ANY($1) AND m.deleted_at IS NULL
+		 ORDER BY a.message_id, a.id`,
+		pq.Array(messageIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindAttachments: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[entity.ID][]*entity.Attachment)
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindAttachments: %w", err)
+		}
+
+		res[attachment.MessageID] = append(res[attachment.MessageID], attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindAttachments: %w", err)
+	}
+
+	return res, nil
+}
+
+// FindUnprocessedImages returns the oldest images posted before the given
+// time which are still to be processed.
+func (r *AttachmentRepository) FindUnprocessedImages(
+	ctx context.Context,
+	postedBefore time.Time,
+) ([]*entity.Attachment, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+attachmentColumns+`
+		 FROM attachments AS a
+		 INNER JOIN messages AS m ON m.id = a.message_id
+		 WHERE a.processed_at IS NULL AND a.created_at < $1 AND m.deleted_at IS NULL
+		 ORDER BY a.created_at
+		 LIMIT $2`,
+		postedBefore, unprocessedImagesLimit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindUnprocessedImages: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*entity.Attachment
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindUnprocessedImages: %w", err)
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindUnprocessedImages: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// ProcessImage strips the location metadata the image may still have, reads
+// its dimensions and makes its thumbnail. Images which can not be decoded are
+// marked processed without them. It returns nil when the image was already
+// processed, e.g. by another process.
+func (r *AttachmentRepository) ProcessImage(
+	ctx context.Context,
+	attachment *entity.Attachment,
+) (*entity.Attachment, error) {
+	fail := func(err error) (*entity.Attachment, error) {
+		return nil, fmt.Errorf("ProcessImage: %w", err)
+	}
+
+	original, err := r.readContent(ctx, attachment.StorageKey)
+	if err != nil {
+		return fail(err)
+	}
+
+	var (
+		size                 = int64(len(original))
+		width, height        *int
+		thumbnailKey         *string
+		thumbnailContentType *string
+	)
+
+	// images stored before their metadata was stripped on upload get it
+	// stripped here
+	content, err := imaging.StripMetadata(original, attachment.ContentType)
+	if err == nil && !bytes.Equal(content, original) {
+		if err := r.blobStore.Put(ctx, attachment.StorageKey, bytes.NewReader(content),
+			int64(len(content)), attachment.ContentType); err != nil {
+			return fail(fmt.Errorf("put stripped content: %w", err))
+		}
+
+		size = int64(len(content))
+	}
+
+	thumbnail, err := imaging.MakeThumbnail(content)
+
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalid):
+	case err != nil:
+		return fail(fmt.Errorf("make thumbnail: %w", err))
+	default:
+		key := thumbnailKeyPrefix + ksuid.New().String()
+		if err := r.blobStore.Put(ctx, key, bytes.NewReader(thumbnail.Content),
+			int64(len(thumbnail.Content)), thumbnail.ContentType); err != nil {
+			return fail(fmt.Errorf("put thumbnail: %w", err))
+		}
+
+		width, height = &thumbnail.Width, &thumbnail.Height
+		thumbnailKey, thumbnailContentType = &key, &thumbnail.ContentType
+	}
+
+	processed, err := scanAttachment(r.db.QueryRowContext(
+		ctx,
+		`WITH a AS (
+			UPDATE attachments
+			   SET size = $2, width = $3, height = $4, thumbnail_key = $5,
+			       thumbnail_content_type = $6, processed_at = NOW()
+			 WHERE id = $1 AND processed_at IS NULL
+			RETURNING *
+		)
+		SELECT `+attachmentColumns+`
+		FROM a
+		INNER JOIN messages AS m ON m.id = a.message_id`,
+		attachment.ID, size, width, height, thumbnailKey, thumbnailContentType,
+	))
+
+	if err != nil && thumbnailKey != nil {
+		// skip error, the thumbnail is attached to nothing
+		_ = r.blobStore.Delete(ctx, *thumbnailKey)
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return fail(err)
+	default:
+		return processed, nil
+	}
+}
+
+func (r *AttachmentRepository) readContent(ctx context.Context, key string) ([]byte, error) {
+	reader, err := r.blobStore.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("get content: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read content: %w", err)
+	}
+
+	return content, nil
+}
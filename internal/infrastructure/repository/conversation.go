@@ -0,0 +1,386 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/infrastructure/repository/model"
+)
+
+// LockConversationWithTransaction returns the conversation and keeps others
+// from changing it until the transaction ends.
+func (r *MessageRepository) LockConversationWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+) (*entity.Conversation, error) {
+	fail := func(err error) (*entity.Conversation, error) {
+		return nil, fmt.Errorf("LockConversationWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	var c model.Conversation
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT id, creator_id, title, type, created_at, updated_at, deleted_at
+		   FROM conversations
+		  WHERE id = $1
+		    FOR UPDATE`,
+		conversationID,
+	).Scan(
+		&c.ID,
+		&c.CreatorID,
+		&c.Title,
+		&c.Type,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.DeletedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fail(fmt.Errorf("conversation %v: %w", conversationID,
+				domainerrors.ErrNotFound))
+		}
+
+		return fail(err)
+	}
+
+	return model.ConvertModelConversation(&c), nil
+}
+
+// FindParticipantsWithTransaction returns the participants of the
+// conversation in the order they joined, without their user.
+func (r *MessageRepository) FindParticipantsWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+) ([]*entity.Participant, error) {
+	fail := func(err error) ([]*entity.Participant, error) {
+		return nil, fmt.Errorf("FindParticipantsWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, conversation_id, user_id, role, created_at, updated_at
+		   FROM participants
+		  WHERE conversation_id = $1
+		  ORDER BY created_at, id`,
+		conversationID,
+	)
+	if err != nil {
+		return fail(err)
+	}
+	defer rows.Close()
+
+	var participants []*entity.Participant
+
+	for rows.Next() {
+		var p model.Participant
+		if err := rows.Scan(
+			&p.ID,
+			&p.ConversationID,
+			&p.UserID,
+			&p.Role,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		); err != nil {
+			return fail(err)
+		}
+
+		participants = append(participants, model.ConvertModelParticipant(&p))
+	}
+
+	if err := rows.Err(); err != nil {
+		return fail(err)
+	}
+
+	return participants, nil
+}
+
+// SetParticipantRoleWithTransaction changes the role of a participant, a
+// conversation has only one owner so the current one must be demoted first.
+func (r *MessageRepository) SetParticipantRoleWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+	userID entity.ID,
+	role entity.ParticipantRole,
+) error {
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fmt.Errorf("SetParticipantRoleWithTransaction: get transaction from ctx failed")
+	}
+
+	if err := setParticipantRole(ctx, tx, conversationID, userID, role); err != nil {
+		return fmt.Errorf("SetParticipantRoleWithTransaction: %w", err)
+	}
+
+	return nil
+}
+
+func setParticipantRole(
+	ctx context.Context,
+	tx *sql.Tx,
+	conversationID entity.ID,
+	userID entity.ID,
+	role entity.ParticipantRole,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE participants
+		    SET role = $3, updated_at = NOW()
+		  WHERE conversation_id = $1 AND user_id = $2`,
+		conversationID, userID, role,
+	)
+
+	return err
+}
+
+func (r *MessageRepository) RenameConversationWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+	title string,
+) error {
+	fail := func(err error) error {
+		return fmt.Errorf("RenameConversationWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE conversations SET title = $2, updated_at = NOW() WHERE id = $1`,
+		conversationID, title,
+	); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+// AddParticipantsWithTransaction adds the users to the conversation and
+// returns the ones which were not participants yet.
+func (r *MessageRepository) AddParticipantsWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+	userIDs []entity.ID,
+) ([]entity.ID, error) {
+	fail := func(err error) ([]entity.ID, error) {
+		return nil, fmt.Errorf("AddParticipantsWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`INSERT INTO participants (conversation_id, user_id)
+		 SELECT CAST($1 AS INTEGER), u.id
+		   FROM users AS u
+		  WHERE u.id = ANY($2)
+		    AND NOT EXISTS (
+		      SELECT 1 FROM participants AS p
+		       WHERE p.conversation_id = $1 AND p.user_id = u.id
+		    )
+		 RETURNING user_id`,
+		conversationID, pq.Array(userIDs),
+	)
+	if err != nil {
+		return fail(err)
+	}
+	defer rows.Close()
+
+	var added []entity.ID
+
+	for rows.Next() {
+		var id entity.ID
+		if err := rows.Scan(&id); err != nil {
+			return fail(err)
+		}
+
+		added = append(added, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fail(err)
+	}
+
+	if len(added) > 0 {
+		if err := r.touchConversation(ctx, conversationID); err != nil {
+			return fail(err)
+		}
+	}
+
+	return added, nil
+}
+
+// RemoveParticipantWithTransaction removes the user from the conversation, it
+// reports false when they were not a participant.
+func (r *MessageRepository) RemoveParticipantWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+	userID entity.ID,
+) (bool, error) {
+	fail := func(err error) (bool, error) {
+		return false, fmt.Errorf("RemoveParticipantWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM participants WHERE conversation_id = $1 AND user_id = $2`,
+		conversationID, userID,
+	)
+	if err != nil {
+		return fail(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fail(err)
+	}
+
+	if affected > 0 {
+		if err := r.touchConversation(ctx, conversationID); err != nil {
+			return fail(err)
+		}
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteConversationWithTransaction soft deletes the conversation, its
+// participants and messages are kept.
+func (r *MessageRepository) DeleteConversationWithTransaction(
+	ctx context.Context,
+	conversationID entity.ID,
+) error {
+	fail := func(err error) error {
+		return fmt.Errorf("DeleteConversationWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE conversations
+		    SET deleted_at = NOW(), updated_at = NOW()
+		  WHERE id = $1 AND deleted_at IS NULL`,
+		conversationID,
+	); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+func (r *MessageRepository) touchConversation(
+	ctx context.Context,
+	conversationID entity.ID,
+) error {
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fmt.Errorf("get transaction from ctx failed")
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE conversations SET updated_at = NOW() WHERE id = $1`, conversationID)
+
+	return err
+}
+
+func (s *MessageRepository) ConversationUpdated(
+	ctx context.Context,
+	input entity.User,
+) (<-chan *entity.ConversationEvent, error) {
+	events := make(chan *entity.ConversationEvent, 1)
+
+	s.conversationSubscriptions.Subscribe(ctx, input.ID,
+		func(ctx context.Context, e interface{}) {
+			event, ok := e.(*entity.ConversationEvent)
+			if !ok {
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		},
+		func() { close(events) },
+	)
+
+	return events, nil
+}
+
+// FanoutConversationEvent publishes the event to the participants of the
+// conversation and to the ones the change removed.
+func (s *MessageRepository) FanoutConversationEvent(
+	ctx context.Context,
+	event *entity.ConversationEvent,
+) error {
+	participantIDs, err := s.findParticipantIDs(ctx, event.Conversation.ID)
+	if err != nil {
+		return fmt.Errorf("FanoutConversationEvent: find participant ids: %w", err)
+	}
+
+	recipientIDs := participantIDs
+	for _, id := range event.UserIDs {
+		if !containsID(participantIDs, id) {
+			recipientIDs = append(recipientIDs, id)
+		}
+	}
+
+	if err := publishEvent(ctx, s.eventBus, topicConversationUpdated, recipientIDs,
+		event); err != nil {
+		return fmt.Errorf("FanoutConversationEvent: %w", err)
+	}
+
+	return nil
+}
+
+func decodeConversationEvent(payload json.RawMessage) (interface{}, error) {
+	var event entity.ConversationEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	if event.Conversation == nil {
+		return nil, fmt.Errorf("conversation event without conversation")
+	}
+
+	return &event, nil
+}
+
+func containsID(ids []entity.ID, id entity.ID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
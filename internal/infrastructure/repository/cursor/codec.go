@@ -0,0 +1,77 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+const signatureSize = 16
+
+// Sort is the order of the list a cursor was issued for, a cursor is only
+// valid in a list sorted the same way.
+type Sort struct {
+	Key   string               `json:"k"`
+	Type  string               `json:"t"`
+	Order entity.SortOrderType `json:"o"`
+}
+
+// Position is what a cursor points at, the sort value of a row and its id.
+type Position struct {
+	Sort
+	Value string    `json:"v"`
+	ID    entity.ID `json:"id"`
+}
+
+// Codec turns positions into signed opaque cursors, so that clients can not
+// forge them.
+type Codec struct {
+	secret []byte
+}
+
+func NewCodec(secret string) *Codec {
+	return &Codec{secret: []byte(secret)}
+}
+
+func (c *Codec) Encode(position Position) entity.Cursor {
+	payload, _ := json.Marshal(&position)
+
+	return entity.Cursor(base64.RawURLEncoding.EncodeToString(
+		append(payload, c.sign(payload)...)))
+}
+
+// Decode returns the position of a cursor issued for a list sorted by sort.
+func (c *Codec) Decode(cursor entity.Cursor, sort Sort) (*Position, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(string(cursor))
+	if err != nil || len(raw) <= signatureSize {
+		return nil, fmt.Errorf("malformed cursor: %w", domainerrors.ErrInvalid)
+	}
+
+	payload, signature := raw[:len(raw)-signatureSize], raw[len(raw)-signatureSize:]
+	if !hmac.Equal(signature, c.sign(payload)) {
+		return nil, fmt.Errorf("cursor signature mismatch: %w", domainerrors.ErrInvalid)
+	}
+
+	var position Position
+	if err := json.Unmarshal(payload, &position); err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", domainerrors.ErrInvalid)
+	}
+
+	if position.Sort != sort {
+		return nil, fmt.Errorf("cursor of another list: %w", domainerrors.ErrInvalid)
+	}
+
+	return &position, nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	_, _ = mac.Write(payload)
+
+	return mac.Sum(nil)[:signatureSize]
+}
@@ -0,0 +1,50 @@
+package cursor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+func TestCodec(t *testing.T) {
+	codec := NewCodec("secret")
+	createdAt := Sort{Key: "created_at", Type: "TIMESTAMPTZ", Order: entity.SortOrderTypeASC}
+	position := Position{Sort: createdAt, Value: "2021-06-24T09:01:00Z", ID: 42}
+
+	cursor := codec.Encode(position)
+
+	decoded, err := codec.Decode(cursor, createdAt)
+	if err != nil {
+		t.Fatalf("got %v, want the position", err)
+	}
+
+	if *decoded != position {
+		t.Errorf("got %+v, want %+v", *decoded, position)
+	}
+
+	refused := []struct {
+		name   string
+		codec  *Codec
+		cursor entity.Cursor
+		sort   Sort
+	}{
+		{"other sort key", codec, cursor,
+			Sort{Key: "updated_at", Type: "TIMESTAMPTZ", Order: entity.SortOrderTypeASC}},
+		{"other sort type", codec, cursor,
+			Sort{Key: "created_at", Type: "REAL", Order: entity.SortOrderTypeASC}},
+		{"other sort order", codec, cursor,
+			Sort{Key: "created_at", Type: "TIMESTAMPTZ", Order: entity.SortOrderTypeDES}},
+		{"other secret", NewCodec("other"), cursor, createdAt},
+		{"tampered", codec, cursor[1:], createdAt},
+		{"malformed", codec, "not a cursor", createdAt},
+		{"empty", codec, "", createdAt},
+	}
+
+	for _, tt := range refused {
+		if _, err := tt.codec.Decode(tt.cursor, tt.sort); !errors.Is(err, domainerrors.ErrInvalid) {
+			t.Errorf("%v: got %v, want %v", tt.name, err, domainerrors.ErrInvalid)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
+)
+
+const (
+	topicMessagePosted       = "message_posted"
+	topicMessageUpdated      = "message_updated"
+	topicMessageDeleted      = "message_deleted"
+	topicReactionChanged     = "reaction_changed"
+	topicTypingChanged       = "typing_changed"
+	topicPresenceChanged     = "presence_changed"
+	topicUserJoined          = "user_joined"
+	topicConversationUpdated = "conversation_updated"
+)
+
+// event is what travels on the event bus, an empty RecipientIDs means every
+// subscriber receives it.
+type event struct {
+	RecipientIDs []entity.ID     `json:"recipient_ids"`
+	Payload      json.RawMessage `json:"payload"`
+}
+
+func publishEvent(
+	ctx context.Context,
+	eventBus external.EventBus,
+	topic string,
+	recipientIDs []entity.ID,
+	payload interface{},
+) error {
+	p, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	e, err := json.Marshal(&event{RecipientIDs: recipientIDs, Payload: p})
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	if err := eventBus.Publish(ctx, topic, e); err != nil {
+		return fmt.Errorf("publish %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// listenEvents hands every event of the topic received from the bus to the
+// local subscriptions, decode turns the payload back into what the
+// subscriptions expect.
+func listenEvents(
+	eventBus external.EventBus,
+	topic string,
+	registry *subscription.Registry,
+	decode func(payload json.RawMessage) (interface{}, error),
+) {
+	eventBus.Subscribe(topic, func(raw []byte) {
+		var e event
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return
+		}
+
+		payload, err := decode(e.Payload)
+		if err != nil {
+			return
+		}
+
+		if len(e.RecipientIDs) == 0 {
+			registry.Broadcast(payload)
+			return
+		}
+
+		registry.Publish(e.RecipientIDs, payload)
+	})
+}
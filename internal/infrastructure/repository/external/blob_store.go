@@ -0,0 +1,14 @@
+package external
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps opaque contents under keys, missing keys are reported as
+// domain not found errors.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
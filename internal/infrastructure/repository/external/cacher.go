@@ -1,8 +1,20 @@
 package external
 
+import "time"
+
 type Cacher interface {
 	LPush(key string, values []byte) error
 	SAdd(key string, values []byte) error
 	LRange(key string, start, stop int64) ([]string, error)
 	SMembers(key string) ([]string, error)
+	HSetWithTTL(key, field string, value []byte, ttl time.Duration) error
+	HDel(key, field string) error
+	HGetAll(keys ...string) ([]map[string]string, error)
+	Set(key string, value []byte) error
+	SetWithTTL(key string, value []byte, ttl time.Duration) error
+	SetNX(key string, value []byte, ttl time.Duration) (bool, error)
+	MGet(keys ...string) ([]*string, error)
+	ZAdd(key string, score float64, member string) error
+	ZRangeByScore(key string, max float64) ([]string, error)
+	ZRem(key, member string) (bool, error)
 }
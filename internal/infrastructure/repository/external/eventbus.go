@@ -0,0 +1,10 @@
+package external
+
+import "context"
+
+// EventBus carries events between every running instance, payloads are opaque
+// to the bus.
+type EventBus interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	Subscribe(topic string, handler func(payload []byte))
+}
@@ -0,0 +1,13 @@
+package external
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+// LinkFetcher reads the preview of a page, it returns nil when the page has
+// none.
+type LinkFetcher interface {
+	Fetch(ctx context.Context, url string) (*entity.LinkPreview, error)
+}
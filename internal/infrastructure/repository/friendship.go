@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/model"
+)
+
+type FriendshipRepository struct {
+	dbTransactor external.Transactor
+	db           *sql.DB
+}
+
+func NewFriendshipRepository(
+	dbTransactor external.Transactor,
+	db *sql.DB,
+) *FriendshipRepository {
+	return &FriendshipRepository{
+		dbTransactor: dbTransactor,
+		db:           db,
+	}
+}
+
+// LockFriendshipsWithTransaction serializes the transactions changing the
+// friendships between the two users, in either direction, until the end of
+// the transaction. Locking the friendships rows is not enough as they may not
+// exist yet, so the rows of both users are locked instead, always in the same
+// order to not deadlock.
+func (r *FriendshipRepository) LockFriendshipsWithTransaction(
+	ctx context.Context,
+	userID entity.ID,
+	otherID entity.ID,
+) error {
+	fail := func(err error) error {
+		return fmt.Errorf("LockFriendshipsWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`SELECT id
+		   FROM users
+		  WHERE id IN ($1, $2)
+		  ORDER BY id
+		    FOR NO KEY UPDATE`,
+		userID, otherID,
+	); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+// FindFriendshipWithTransaction returns what userID did towards friendID,
+// nil when nothing. The row is locked until the end of the transaction.
+func (r *FriendshipRepository) FindFriendshipWithTransaction(
+	ctx context.Context,
+	userID entity.ID,
+	friendID entity.ID,
+) (*entity.Friendship, error) {
+	fail := func(err error) (*entity.Friendship, error) {
+		return nil, fmt.Errorf("FindFriendshipWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	var friendship model.Friendship
+	err := tx.QueryRowContext(ctx,
+		`SELECT user_id, friend_id, status, created_at, updated_at
+		   FROM friendships
+		  WHERE user_id = $1 AND friend_id = $2
+		  FOR UPDATE`,
+		userID, friendID,
+	).Scan(
+		&friendship.UserID,
+		&friendship.FriendID,
+		&friendship.Status,
+		&friendship.CreatedAt,
+		&friendship.UpdatedAt,
+	)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return fail(err)
+	default:
+		return model.ConvertModelFriendship(&friendship), nil
+	}
+}
+
+// SaveFriendshipWithTransaction creates the friendship of userID towards
+// friendID or changes its status.
+func (r *FriendshipRepository) SaveFriendshipWithTransaction(
+	ctx context.Context,
+	userID entity.ID,
+	friendID entity.ID,
+	status entity.FriendshipStatus,
+) (*entity.Friendship, error) {
+	fail := func(err error) (*entity.Friendship, error) {
+		return nil, fmt.Errorf("SaveFriendshipWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	var friendship model.Friendship
+	err := tx.QueryRowContext(ctx,
+		`INSERT INTO friendships(user_id, friend_id, status)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, friend_id)
+		 DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()
+		 RETURNING user_id, friend_id, status, created_at, updated_at`,
+		userID, friendID, status,
+	).Scan(
+		&friendship.UserID,
+		&friendship.FriendID,
+		&friendship.Status,
+		&friendship.CreatedAt,
+		&friendship.UpdatedAt,
+	)
+	if err != nil {
+		return fail(err)
+	}
+
+	return model.ConvertModelFriendship(&friendship), nil
+}
+
+func (r *FriendshipRepository) DeleteFriendshipWithTransaction(
+	ctx context.Context,
+	userID entity.ID,
+	friendID entity.ID,
+) error {
+	fail := func(err error) error {
+		return fmt.Errorf("DeleteFriendshipWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM friendships WHERE user_id = $1 AND friend_id = $2`,
+		userID, friendID,
+	); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+// FindPendingFriendRequests returns the requests sent to userID which are
+// still waiting for an answer, latest first.
+func (r *FriendshipRepository) FindPendingFriendRequests(
+	ctx context.Context,
+	userID entity.ID,
+) ([]*entity.FriendRequest, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT user_id, created_at
+		   FROM friendships
+		  WHERE friend_id = $1 AND status = $2
+		  ORDER BY created_at DESC, id DESC`,
+		userID, entity.FriendshipStatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindPendingFriendRequests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*entity.FriendRequest
+	for rows.Next() {
+		var request entity.FriendRequest
+		if err := rows.Scan(&request.SenderID, &request.CreatedAt); err != nil {
+			return nil, fmt.Errorf("FindPendingFriendRequests: %w", err)
+		}
+
+		requests = append(requests, &request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindPendingFriendRequests: %w", err)
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,289 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+const (
+	jpegAPP1  = 0xE1 // Exif and XMP
+	jpegAPP13 = 0xED // Photoshop and IPTC
+	jpegSOS   = 0xDA
+	jpegEOI   = 0xD9
+
+	exifOrientationTag = 0x0112
+	tiffTypeShort      = 3
+)
+
+var (
+	exifHeader   = []byte("Exif\x00\x00")
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// StripMetadata returns the image without the metadata telling where, when
+// and with what it was taken. Only the orientation of JPEG images is kept,
+// images would be shown rotated otherwise. Contents of other types are
+// returned as is.
+func StripMetadata(content []byte, contentType string) ([]byte, error) {
+	var (
+		stripped []byte
+		err      error
+	)
+
+	switch contentType {
+	case "image/jpeg":
+		stripped, err = stripJPEG(content)
+	case "image/png":
+		stripped, err = stripPNG(content)
+	case "image/webp":
+		stripped, err = stripWebP(content)
+	default:
+		return content, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("malformed %v: %v: %w", contentType, err, domainerrors.ErrInvalid)
+	}
+
+	return stripped, nil
+}
+
+// jpegSegments splits a JPEG image into the marker segments preceding its
+// image data, SOI excluded, and the rest starting at the start of scan.
+func jpegSegments(content []byte) ([][]byte, []byte, error) {
+	if len(content) < 2 || content[0] != 0xFF || content[1] != 0xD8 {
+		return nil, nil, fmt.Errorf("missing start of image")
+	}
+
+	var segments [][]byte
+
+	for i := 2; ; {
+		if i+2 > len(content) || content[i] != 0xFF {
+			return nil, nil, fmt.Errorf("missing marker at %v", i)
+		}
+
+		marker := content[i+1]
+		switch {
+		case marker == 0xFF:
+			// fill byte
+			i++
+			continue
+		case marker == jpegSOS || marker == jpegEOI:
+			return segments, content[i:], nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// markers without payload
+			segments = append(segments, content[i:i+2])
+			i += 2
+			continue
+		}
+
+		if i+4 > len(content) {
+			return nil, nil, fmt.Errorf("truncated segment at %v", i)
+		}
+
+		end := i + 2 + int(binary.BigEndian.Uint16(content[i+2:]))
+		if end < i+4 || end > len(content) {
+			return nil, nil, fmt.Errorf("truncated segment at %v", i)
+		}
+
+		segments = append(segments, content[i:end])
+		i = end
+	}
+}
+
+// stripJPEG drops the Exif, XMP and IPTC segments, an Exif segment holding
+// only the orientation takes the place of the original one.
+func stripJPEG(content []byte) ([]byte, error) {
+	segments, rest, err := jpegSegments(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	out.Write(content[:2])
+
+	orientation := 1
+	exifAt := -1
+
+	for _, segment := range segments {
+		switch segment[1] {
+		case jpegAPP1:
+			if bytes.HasPrefix(segment[4:], exifHeader) && exifAt < 0 {
+				orientation = exifOrientation(segment[4+len(exifHeader):])
+				exifAt = out.Len()
+			}
+		case jpegAPP13:
+		default:
+			out.Write(segment)
+		}
+	}
+
+	out.Write(rest)
+
+	if orientation == 1 {
+		return out.Bytes(), nil
+	}
+
+	stripped := out.Bytes()
+	exif := orientationSegment(orientation)
+
+	return append(append(append(make([]byte, 0, len(stripped)+len(exif)),
+		stripped[:exifAt]...), exif...), stripped[exifAt:]...), nil
+}
+
+// jpegOrientation returns the Exif orientation of a JPEG image, 1 when it
+// has none.
+func jpegOrientation(content []byte) int {
+	segments, _, err := jpegSegments(content)
+	if err != nil {
+		return 1
+	}
+
+	for _, segment := range segments {
+		if segment[1] == jpegAPP1 && bytes.HasPrefix(segment[4:], exifHeader) {
+			return exifOrientation(segment[4+len(exifHeader):])
+		}
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag of the first IFD of a TIFF
+// structure, 1 when it is missing or invalid.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+
+		if order.Uint16(tiff[entry:]) != exifOrientationTag ||
+			order.Uint16(tiff[entry+2:]) != tiffTypeShort {
+			continue
+		}
+
+		if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// orientationSegment returns an APP1 segment with an Exif structure holding
+// the orientation only.
+func orientationSegment(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // header, first IFD at 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, tiffTypeShort, 0x00, 0x00, 0x00, 0x01, byte(orientation >> 8), byte(orientation), 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	segment := []byte{0xFF, jpegAPP1, 0x00, 0x00}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	return append(segment, payload...)
+}
+
+// stripPNG drops the Exif and text chunks, the latter may carry XMP.
+func stripPNG(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, pngSignature) {
+		return nil, fmt.Errorf("missing signature")
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	out.Write(pngSignature)
+
+	for i := len(pngSignature); i < len(content); {
+		if i+8 > len(content) {
+			return nil, fmt.Errorf("truncated chunk at %v", i)
+		}
+
+		end := i + 12 + int(binary.BigEndian.Uint32(content[i:]))
+		if end < i+12 || end > len(content) {
+			return nil, fmt.Errorf("truncated chunk at %v", i)
+		}
+
+		switch string(content[i+4 : i+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt":
+		default:
+			out.Write(content[i:end])
+		}
+
+		if string(content[i+4:i+8]) == "IEND" {
+			break
+		}
+
+		i = end
+	}
+
+	return out.Bytes(), nil
+}
+
+// stripWebP drops the EXIF and XMP chunks and their flags in the extended
+// header.
+func stripWebP(content []byte) ([]byte, error) {
+	if len(content) < 12 || string(content[:4]) != "RIFF" || string(content[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("missing RIFF header")
+	}
+
+	out := make([]byte, 0, len(content))
+	out = append(out, content[:12]...)
+
+	for i := 12; i < len(content); {
+		if i+8 > len(content) {
+			return nil, fmt.Errorf("truncated chunk at %v", i)
+		}
+
+		size := int(binary.LittleEndian.Uint32(content[i+4:]))
+		end := i + 8 + size + size%2
+		if end < i+8 || end > len(content) {
+			return nil, fmt.Errorf("truncated chunk at %v", i)
+		}
+
+		switch string(content[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, content[i:end]...)
+			if size > 0 {
+				out[start+8] &^= 0x08 | 0x04 // EXIF and XMP flags
+			}
+		default:
+			out = append(out, content[i:end]...)
+		}
+
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+
+	return out, nil
+}
@@ -0,0 +1,197 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	// decoders of the image formats thumbnails are made for
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+const (
+	// ThumbnailSize bounds the width and height of the thumbnails.
+	ThumbnailSize = 320
+	// maxPixels refuses images whose decoding would take too much memory,
+	// about 100MB once decoded to RGBA.
+	maxPixels = 24 << 20
+	// thumbnailQuality is the JPEG quality of opaque thumbnails.
+	thumbnailQuality = 80
+)
+
+// Thumbnail is a preview of an image, Width and Height are the ones of the
+// image as displayed.
+type Thumbnail struct {
+	Content     []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// MakeThumbnail scales the image down to fit ThumbnailSize, it is encoded as
+// JPEG unless it has transparency. Images of unknown formats are reported
+// invalid.
+func MakeThumbnail(content []byte) (*Thumbnail, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode config: %v: %w", err, domainerrors.ErrInvalid)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("image of %vx%v: %w", config.Width, config.Height,
+			domainerrors.ErrInvalid)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %v: %w", err, domainerrors.ErrInvalid)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(content)
+	}
+
+	thumbnail := orient(scaleDown(src, ThumbnailSize), orientation)
+
+	width, height := config.Width, config.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	var (
+		out         bytes.Buffer
+		contentType string
+	)
+
+	if thumbnail.Opaque() {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&out, thumbnail, &jpeg.Options{Quality: thumbnailQuality})
+	} else {
+		contentType = "image/png"
+		err = png.Encode(&out, thumbnail)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	return &Thumbnail{
+		Content:     out.Bytes(),
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+	}, nil
+}
+
+// scaleDown fits the image within size keeping its aspect ratio, each pixel
+// averages the box of pixels it covers. Smaller images keep their size. The
+// source is converted to RGBA a strip of rows at a time, never as a whole.
+func scaleDown(src image.Image, size int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if w <= size && h <= size {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+		return dst
+	}
+
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+
+	if tw < 1 {
+		tw = 1
+	}
+
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	// the rows of the source covered by one row of the thumbnail
+	strip := image.NewRGBA(image.Rect(0, 0, w, (h+th-1)/th))
+
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, (y+1)*h/th
+		draw.Draw(strip, image.Rect(0, 0, w, y1-y0), src,
+			image.Pt(bounds.Min.X, bounds.Min.Y+y0), draw.Src)
+
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, (x+1)*w/tw
+
+			var sum [4]uint64
+			for sy := 0; sy < y1-y0; sy++ {
+				row := strip.Pix[sy*strip.Stride+x0*4 : sy*strip.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += uint64(row[i])
+					sum[1] += uint64(row[i+1])
+					sum[2] += uint64(row[i+2])
+					sum[3] += uint64(row[i+3])
+				}
+			}
+
+			n := uint64((y1 - y0) * (x1 - x0))
+			pixel := dst.Pix[y*dst.Stride+x*4:]
+			for c := 0; c < 4; c++ {
+				pixel[c] = uint8(sum[c] / n)
+			}
+		}
+	}
+
+	return dst
+}
+
+// orient applies an Exif orientation so that the image is shown upright.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90° counterclockwise
+				sx, sy = w-1-y, x
+			}
+
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4],
+				src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+		}
+	}
+
+	return dst
+}
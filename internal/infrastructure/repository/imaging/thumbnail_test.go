@@ -0,0 +1,141 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"testing"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+func sampleImages(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	src := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for x := 0; x < 640; x++ {
+		for y := 0; y < 480; y++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var gifImage, jpegImage, pngImage bytes.Buffer
+	if err := gif.Encode(&gifImage, src, nil); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+
+	if err := jpeg.Encode(&jpegImage, src, nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+
+	if err := png.Encode(&pngImage, src); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	// there is no WebP encoder in Go, the sample comes from golang.org/x/image
+	webpImage, err := ioutil.ReadFile("testdata/blue-purple-pink.lossy.webp")
+	if err != nil {
+		t.Fatalf("read webp: %v", err)
+	}
+
+	return map[string][]byte{
+		"image/gif":  gifImage.Bytes(),
+		"image/jpeg": jpegImage.Bytes(),
+		"image/png":  pngImage.Bytes(),
+		"image/webp": webpImage,
+	}
+}
+
+// TestMakeThumbnail_ImageContentTypes makes sure that every content type
+// sent as an image gets a thumbnail, i.e. has its decoder registered.
+func TestMakeThumbnail_ImageContentTypes(t *testing.T) {
+	samples := sampleImages(t)
+
+	for _, contentType := range entity.ImageContentTypes() {
+		sample, ok := samples[contentType]
+		if !ok {
+			t.Errorf("%v: no sample image", contentType)
+			continue
+		}
+
+		thumbnail, err := MakeThumbnail(sample)
+		if err != nil {
+			t.Errorf("%v: %v", contentType, err)
+			continue
+		}
+
+		config, _, err := image.DecodeConfig(bytes.NewReader(thumbnail.Content))
+		if err != nil {
+			t.Errorf("%v: decode thumbnail: %v", contentType, err)
+			continue
+		}
+
+		if config.Width > ThumbnailSize || config.Height > ThumbnailSize {
+			t.Errorf("%v: got thumbnail of %vx%v, want at most %v", contentType,
+				config.Width, config.Height, ThumbnailSize)
+		}
+	}
+}
+
+func TestMakeThumbnail_TooManyPixels(t *testing.T) {
+	// the header of a PNG of 6000x5000 is enough, the size is checked before
+	// the pixels are decoded
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], 6000)
+	binary.BigEndian.PutUint32(ihdr[8:], 5000)
+	ihdr[12], ihdr[13] = 8, 6 // 8 bits RGBA
+
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(ihdr))
+
+	header := []byte("\x89PNG\r\n\x1a\n")
+	header = append(header, 0, 0, 0, 13)
+	header = append(header, ihdr...)
+	header = append(header, crc...)
+
+	if _, err := MakeThumbnail(header); !errors.Is(err, domainerrors.ErrInvalid) {
+		t.Errorf("got %v, want %v", err, domainerrors.ErrInvalid)
+	}
+}
+
+func TestScaleDown(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+
+	// bounds not starting at the origin, left half red and right half blue
+	src := image.NewNRGBA(image.Rect(10, 20, 1010, 520))
+	for y := 20; y < 520; y++ {
+		for x := 10; x < 1010; x++ {
+			if x < 510 {
+				src.SetNRGBA(x, y, red)
+			} else {
+				src.SetNRGBA(x, y, blue)
+			}
+		}
+	}
+
+	dst := scaleDown(src, ThumbnailSize)
+
+	if got := dst.Bounds().Size(); got != image.Pt(320, 160) {
+		t.Fatalf("got %v, want 320x160", got)
+	}
+
+	for _, y := range []int{0, 80, 159} {
+		if got := dst.RGBAAt(0, y); got != (color.RGBA{R: 255, A: 255}) {
+			t.Errorf("left of row %v: got %v, want red", y, got)
+		}
+
+		if got := dst.RGBAAt(319, y); got != (color.RGBA{B: 255, A: 255}) {
+			t.Errorf("right of row %v: got %v, want blue", y, got)
+		}
+	}
+}
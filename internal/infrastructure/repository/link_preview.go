@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+)
+
+const (
+	// linkPreviewKeyPrefix holds per link its cached preview.
+	linkPreviewKeyPrefix = "link_preview:"
+	// linkPreviewLockKeyPrefix marks per link that a process is fetching it.
+	linkPreviewLockKeyPrefix = "link_preview_lock:"
+	// linkPreviewTTL is how long a preview is kept, it is fetched again when
+	// a message having the link is read after.
+	linkPreviewTTL = 24 * time.Hour
+	// missingLinkPreviewTTL is how long a link without preview, or which
+	// could not be fetched, is not fetched again.
+	missingLinkPreviewTTL = time.Hour
+	linkPreviewLockTTL    = 30 * time.Second
+)
+
+// cachedLinkPreview is what is cached per link, Preview is nil for the links
+// without preview.
+type cachedLinkPreview struct {
+	Preview *entity.LinkPreview `json:"preview"`
+}
+
+type LinkPreviewRepository struct {
+	cacher  external.Cacher
+	fetcher external.LinkFetcher
+}
+
+func NewLinkPreviewRepository(
+	cacher external.Cacher,
+	fetcher external.LinkFetcher,
+) *LinkPreviewRepository {
+	return &LinkPreviewRepository{
+		cacher:  cacher,
+		fetcher: fetcher,
+	}
+}
+
+// linkKey hashes the link, links are of any length.
+func linkKey(link string) string {
+	sum := sha256.Sum256([]byte(link))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// FindLinkPreviews returns the cached previews of the links, the links
+// without one are left out. It returns as well the links which are not
+// cached, never fetched or expired, so that they get fetched again.
+func (r *LinkPreviewRepository) FindLinkPreviews(
+	ctx context.Context,
+	links []string,
+) (map[string]*entity.LinkPreview, []string, error) {
+	res := make(map[string]*entity.LinkPreview)
+	if len(links) == 0 {
+		return res, nil, nil
+	}
+
+	keys := make([]string, 0, len(links))
+	for _, link := range links {
+		keys = append(keys, linkPreviewKeyPrefix+linkKey(link))
+	}
+
+	values, err := r.cacher.MGet(keys...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("FindLinkPreviews: %w", err)
+	}
+
+	var uncached []string
+
+	for i, value := range values {
+		if value == nil {
+			uncached = append(uncached, links[i])
+			continue
+		}
+
+		var cached cachedLinkPreview
+		if err := json.Unmarshal([]byte(*value), &cached); err != nil {
+			continue
+		}
+
+		if cached.Preview != nil {
+			res[links[i]] = cached.Preview
+		}
+	}
+
+	return res, uncached, nil
+}
+
+// UnfurlLink fetches and caches the preview of the link, unless it is cached
+// already or being fetched by another process. It reports whether a preview
+// was cached.
+func (r *LinkPreviewRepository) UnfurlLink(ctx context.Context, link string) (bool, error) {
+	fail := func(err error) (bool, error) {
+		return false, fmt.Errorf("UnfurlLink: %w", err)
+	}
+
+	key := linkKey(link)
+
+	values, err := r.cacher.MGet(linkPreviewKeyPrefix + key)
+	if err != nil {
+		return fail(err)
+	}
+
+	if len(values) > 0 && values[0] != nil {
+		return false, nil
+	}
+
+	locked, err := r.cacher.SetNX(linkPreviewLockKeyPrefix+key, []byte("1"), linkPreviewLockTTL)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !locked {
+		return false, nil
+	}
+
+	preview, fetchErr := r.fetcher.Fetch(ctx, link)
+
+	ttl := linkPreviewTTL
+	if fetchErr != nil || preview == nil {
+		preview, ttl = nil, missingLinkPreviewTTL
+	}
+
+	payload, err := json.Marshal(&cachedLinkPreview{Preview: preview})
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := r.cacher.SetWithTTL(linkPreviewKeyPrefix+key, payload, ttl); err != nil {
+		return fail(err)
+	}
+
+	if fetchErr != nil {
+		return fail(fetchErr)
+	}
+
+	return preview != nil, nil
+}
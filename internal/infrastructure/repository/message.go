@@ -3,36 +3,106 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/infrastructure/repository/cursor"
 	"github.com/samthehai/chat/internal/infrastructure/repository/external"
 	"github.com/samthehai/chat/internal/infrastructure/repository/model"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
 )
 
 type MessageRepository struct {
-	cacher       external.Cacher
-	msgChans     map[entity.ID]chan *entity.Message
-	mutex        sync.RWMutex
-	dbTransactor external.Transactor
-	db           *sql.DB
+	cacher                    external.Cacher
+	eventBus                  external.EventBus
+	messageSubscriptions      *subscription.Registry
+	updateSubscriptions       *subscription.Registry
+	deleteSubscriptions       *subscription.Registry
+	reactionSubscriptions     *subscription.Registry
+	typingSubscriptions       *subscription.Registry
+	conversationSubscriptions *subscription.Registry
+	dbTransactor              external.Transactor
+	cursorCodec               *cursor.Codec
+	db                        *sql.DB
 }
 
 func NewMessageRepository(
 	cacher external.Cacher,
+	eventBus external.EventBus,
 	dbTransactor external.Transactor,
 	db *sql.DB,
+	subscriptionOption subscription.Option,
+	cursorCodec *cursor.Codec,
 ) *MessageRepository {
-	return &MessageRepository{
-		cacher:       cacher,
-		dbTransactor: dbTransactor,
-		db:           db,
-		msgChans:     map[entity.ID]chan *entity.Message{},
-		mutex:        sync.RWMutex{},
+	r := &MessageRepository{
+		cacher:                cacher,
+		eventBus:              eventBus,
+		dbTransactor:          dbTransactor,
+		cursorCodec:           cursorCodec,
+		db:                    db,
+		messageSubscriptions:  subscription.NewRegistry(topicMessagePosted, subscriptionOption),
+		updateSubscriptions:   subscription.NewRegistry(topicMessageUpdated, subscriptionOption),
+		deleteSubscriptions:   subscription.NewRegistry(topicMessageDeleted, subscriptionOption),
+		reactionSubscriptions: subscription.NewRegistry(topicReactionChanged, subscriptionOption),
+		typingSubscriptions:   subscription.NewRegistry(topicTypingChanged, subscriptionOption),
+		conversationSubscriptions: subscription.NewRegistry(topicConversationUpdated,
+			subscriptionOption),
 	}
+
+	listenEvents(eventBus, topicMessagePosted, r.messageSubscriptions, decodeMessage)
+	listenEvents(eventBus, topicMessageUpdated, r.updateSubscriptions, decodeMessage)
+	listenEvents(eventBus, topicMessageDeleted, r.deleteSubscriptions, decodeMessage)
+	listenEvents(eventBus, topicReactionChanged, r.reactionSubscriptions, decodeReactionEvent)
+	listenEvents(eventBus, topicConversationUpdated, r.conversationSubscriptions,
+		decodeConversationEvent)
+	newTypingTracker(eventBus, r.typingSubscriptions)
+
+	return r
+}
+
+// messageColumns are the columns scanned by scanMessage.
+const messageColumns = "id, conversation_id, sender_id, type, content, created_at, updated_at, " +
+	"edited_at, deleted_at, parent_id, reply_count, last_reply_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMessage(row rowScanner) (*model.Message, error) {
+	var message model.Message
+	if err := row.Scan(
+		&message.ID,
+		&message.ConversationID,
+		&message.SenderID,
+		&message.Type,
+		&message.Content,
+		&message.CreatedAt,
+		&message.UpdatedAt,
+		&message.EditedAt,
+		&message.DeletedAt,
+		&message.ParentID,
+		&message.ReplyCount,
+		&message.LastReplyAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func decodeMessage(payload json.RawMessage) (interface{}, error) {
+	var message entity.Message
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
 }
 
 func (r *MessageRepository) CreateConversationWithTransaction(
@@ -108,10 +178,26 @@ func (r *MessageRepository) createConversation(
 		return nil, fmt.Errorf("exec context: %w", err)
 	}
 
-	if err := r.createParticipants(ctx, tx, createdID, recipentIDs); err != nil {
+	// a user takes part once, even when listed again
+	participantIDs := []entity.ID{creatorID}
+	for _, id := range recipentIDs {
+		if !containsID(participantIDs, id) {
+			participantIDs = append(participantIDs, id)
+		}
+	}
+
+	if err := r.createParticipants(ctx, tx, createdID, participantIDs); err != nil {
 		return nil, fmt.Errorf("create participants: %w", err)
 	}
 
+	// the creator owns a group
+	if conversationType == entity.ConversationTypeGroup {
+		if err := setParticipantRole(ctx, tx, createdID, creatorID,
+			entity.ParticipantRoleOwner); err != nil {
+			return nil, fmt.Errorf("set owner: %w", err)
+		}
+	}
+
 	return &createdID, nil
 }
 
@@ -124,7 +210,7 @@ func (r *MessageRepository) createParticipants(
 	vStrs := []string{}
 	vArgs := []interface{}{}
 	for index, id := range recipentIDs {
-		vStrs = append(vStrs, fmt.Sprintf("($%v, $%v)", index+1, index+2))
+		vStrs = append(vStrs, fmt.Sprintf("($%v, $%v)", 2*index+1, 2*index+2))
 
 		vArgs = append(vArgs, conversationID)
 		vArgs = append(vArgs, id)
@@ -233,6 +319,7 @@ func (r *MessageRepository) CreateMessageWithTransaction(
 	msgType entity.MessageType,
 	senderID entity.ID,
 	msg string,
+	parentID *entity.ID,
 ) (*entity.Message, error) {
 	fail := func(err error) (*entity.Message, error) {
 		return nil, fmt.Errorf("CreateMessageWithTransaction: %w", err)
@@ -243,7 +330,7 @@ func (r *MessageRepository) CreateMessageWithTransaction(
 		return nil, fmt.Errorf("get transaction from ctx failed")
 	}
 
-	message, err := r.createMessage(ctx, tx, conversationID, msgType, senderID, msg)
+	message, err := r.createMessage(ctx, tx, conversationID, msgType, senderID, msg, parentID)
 	if err != nil {
 		return fail(err)
 	}
@@ -257,6 +344,7 @@ func (r *MessageRepository) CreateMessage(
 	msgType entity.MessageType,
 	senderID entity.ID,
 	msg string,
+	parentID *entity.ID,
 ) (*entity.Message, error) {
 	fail := func(err error) (*entity.Message, error) {
 		return nil, fmt.Errorf("CreateMessage: %w", err)
@@ -268,7 +356,7 @@ func (r *MessageRepository) CreateMessage(
 	}
 	defer tx.Rollback()
 
-	message, err := r.createMessage(ctx, tx, conversationID, msgType, senderID, msg)
+	message, err := r.createMessage(ctx, tx, conversationID, msgType, senderID, msg, parentID)
 	if err != nil {
 		return fail(err)
 	}
@@ -283,35 +371,35 @@ func (r *MessageRepository) createMessage(
 	msgType entity.MessageType,
 	senderID entity.ID,
 	msg string,
+	parentID *entity.ID,
 ) (*entity.Message, error) {
+	// a reply bumps the thread of its parent
 	stmt, err := tx.PrepareContext(
 		ctx,
-		`INSERT INTO messages(conversation_id, sender_id, type, content)
-		 VALUES ($1, $2, $3, $4)
-		 RETURNING id, conversation_id, sender_id, type, content, created_at, updated_at, deleted_at`,
+		`WITH message AS (
+			INSERT INTO messages(conversation_id, sender_id, type, content, parent_id)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING `+messageColumns+`
+		), thread AS (
+			UPDATE messages AS p
+			   SET reply_count = p.reply_count + 1, last_reply_at = m.created_at
+			  FROM message AS m
+			 WHERE p.id = m.parent_id
+		)
+		SELECT `+messageColumns+` FROM message`,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("prepare context: %w", err)
 	}
 	defer stmt.Close()
 
-	var message entity.Message
-	err = stmt.QueryRowContext(ctx, conversationID, senderID, msgType, msg).
-		Scan(
-			&message.ID,
-			&message.ConversationID,
-			&message.SenderID,
-			&message.Type,
-			&message.Content,
-			&message.CreatedAt,
-			&message.UpdatedAt,
-			&message.DeletedAt,
-		)
+	message, err := scanMessage(stmt.QueryRowContext(ctx, conversationID, senderID, msgType, msg,
+		parentID))
 	if err != nil {
 		return nil, fmt.Errorf("exec context: %w", err)
 	}
 
-	return &message, nil
+	return model.ConvertModelMessage(message), nil
 }
 
 func (r *MessageRepository) FindAllMessagesInConversations(
@@ -320,7 +408,7 @@ func (r *MessageRepository) FindAllMessagesInConversations(
 ) (map[entity.ID][]*entity.Message, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT id, conversation_id, sender_id, type, content, created_at, updated_at, deleted_at
+		`SELECT `+messageColumns+`
 		 FROM messages
 		 WHERE conversation_id = ANY($1)`,
 		pq.Array(conversationIDs),
@@ -333,21 +421,12 @@ func (r *MessageRepository) FindAllMessagesInConversations(
 	var messages []*model.Message
 
 	for rows.Next() {
-		var message model.Message
-		if err := rows.Scan(
-			&message.ID,
-			&message.ConversationID,
-			&message.SenderID,
-			&message.Type,
-			&message.Content,
-			&message.CreatedAt,
-			&message.UpdatedAt,
-			&message.DeletedAt,
-		); err != nil {
+		message, err := scanMessage(rows)
+		if err != nil {
 			return nil, err
 		}
 
-		messages = append(messages, &message)
+		messages = append(messages, message)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -366,14 +445,18 @@ func (r *MessageRepository) FindAllMessagesInConversations(
 	return result, nil
 }
 
+// FindParticipantsInConversations returns the participants of each
+// conversation with their user, in the order they joined.
 func (r *MessageRepository) FindParticipantsInConversations(ctx context.Context,
-	conversationIDs []entity.ID) (map[entity.ID][]*entity.User, error) {
+	conversationIDs []entity.ID) (map[entity.ID][]*entity.Participant, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT u.id, u.name, u.picture_url, u.firebase_id, u.provider, u.email_address, u.email_verified, p.conversation_id
+		`SELECT u.id, u.name, u.picture_url, u.firebase_id, u.provider, u.email_address, u.email_verified,
+		        p.id, p.conversation_id, p.user_id, p.role, p.created_at, p.updated_at
 		 FROM users AS u
 		 INNER JOIN participants AS p ON u.id = p.user_id
-		 WHERE p.conversation_id = ANY($1)`,
+		 WHERE p.conversation_id = ANY($1)
+		 ORDER BY p.created_at, p.id`,
 		pq.Array(conversationIDs),
 	)
 	if err != nil {
@@ -381,206 +464,364 @@ func (r *MessageRepository) FindParticipantsInConversations(ctx context.Context,
 	}
 	defer rows.Close()
 
-	type participantWithConversationID struct {
-		ConversationID entity.ID
-		User           model.User
-	}
-
-	var participants []participantWithConversationID
+	result := make(map[entity.ID][]*entity.Participant)
 
 	for rows.Next() {
-		var participant participantWithConversationID
+		var (
+			user        model.User
+			participant model.Participant
+		)
+
 		if err := rows.Scan(
-			&participant.User.ID,
-			&participant.User.Name,
-			&participant.User.PictureUrl,
-			&participant.User.FirebaseID,
-			&participant.User.Provider,
-			&participant.User.EmailAddress,
-			&participant.User.EmailVerified,
+			&user.ID,
+			&user.Name,
+			&user.PictureUrl,
+			&user.FirebaseID,
+			&user.Provider,
+			&user.EmailAddress,
+			&user.EmailVerified,
+			&participant.ID,
 			&participant.ConversationID,
+			&participant.UserID,
+			&participant.Role,
+			&participant.CreatedAt,
+			&participant.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
 
-		participants = append(participants, participant)
+		p := model.ConvertModelParticipant(&participant)
+		p.User = model.ConvertModelUser(&user)
+
+		result[p.ConversationID] = append(result[p.ConversationID], p)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	result := make(map[entity.ID][]*entity.User)
-	for _, p := range participants {
-		if _, ok := result[p.ConversationID]; !ok {
-			result[p.ConversationID] = make([]*entity.User, 0)
+	return result, nil
+}
+
+func (r *MessageRepository) FindConversationIDsByParticipant(ctx context.Context,
+	userID entity.ID, conversationIDs []entity.ID) ([]entity.ID, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT DISTINCT p.conversation_id
+		 FROM participants AS p
+		 INNER JOIN conversations AS c ON c.id = p.conversation_id
+		 WHERE p.user_id = $1 AND p.conversation_id = ANY($2) AND c.deleted_at IS NULL`,
+		userID,
+		pq.Array(conversationIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []entity.ID
+
+	for rows.Next() {
+		var id entity.ID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
 
-		result[p.ConversationID] = append(result[p.ConversationID], model.ConvertModelUser(&p.User))
+		ids = append(ids, id)
 	}
 
-	return result, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
 }
 
 func (s *MessageRepository) MessagePosted(
 	ctx context.Context,
 	input entity.User,
+	conversationID *entity.ID,
 ) (<-chan *entity.Message, error) {
-	messages := make(chan *entity.Message, 1)
-	s.mutex.Lock()
-	s.msgChans[input.ID] = messages
-	s.mutex.Unlock()
+	return s.subscribeMessages(ctx, s.messageSubscriptions, input.ID, conversationID), nil
+}
 
-	go func() {
-		<-ctx.Done()
+func (s *MessageRepository) FanoutMessage(
+	ctx context.Context,
+	message *entity.Message,
+) error {
+	if err := s.fanoutMessage(ctx, topicMessagePosted, message); err != nil {
+		return fmt.Errorf("FanoutMessage: %w", err)
+	}
 
-		s.mutex.Lock()
-		delete(s.msgChans, input.ID)
-		s.mutex.Unlock()
-	}()
+	return nil
+}
 
-	return messages, nil
+func (s *MessageRepository) MessageUpdated(
+	ctx context.Context,
+	input entity.User,
+	conversationID *entity.ID,
+) (<-chan *entity.Message, error) {
+	return s.subscribeMessages(ctx, s.updateSubscriptions, input.ID, conversationID), nil
 }
 
-func (s *MessageRepository) FanoutMessage(
+func (s *MessageRepository) FanoutMessageUpdate(
 	ctx context.Context,
 	message *entity.Message,
-) {
-	s.mutex.RLock()
-	for _, c := range s.msgChans {
-		c <- message
+) error {
+	if err := s.fanoutMessage(ctx, topicMessageUpdated, message); err != nil {
+		return fmt.Errorf("FanoutMessageUpdate: %w", err)
 	}
-	s.mutex.RUnlock()
+
+	return nil
+}
+
+// subscribeMessages streams the messages published to the registry for the
+// user, only the ones of conversationID when given.
+func (s *MessageRepository) subscribeMessages(
+	ctx context.Context,
+	registry *subscription.Registry,
+	userID entity.ID,
+	conversationID *entity.ID,
+) <-chan *entity.Message {
+	messages := make(chan *entity.Message, 1)
+
+	registry.Subscribe(ctx, userID,
+		func(ctx context.Context, event interface{}) {
+			message, ok := event.(*entity.Message)
+			if !ok {
+				return
+			}
+
+			if conversationID != nil && *conversationID != message.ConversationID {
+				return
+			}
+
+			select {
+			case messages <- message:
+			case <-ctx.Done():
+			}
+		},
+		func() { close(messages) },
+	)
+
+	return messages
+}
+
+// fanoutMessage publishes the message to the participants of its
+// conversation.
+func (s *MessageRepository) fanoutMessage(
+	ctx context.Context,
+	topic string,
+	message *entity.Message,
+) error {
+	participantIDs, err := s.findParticipantIDs(ctx, message.ConversationID)
+	if err != nil {
+		return fmt.Errorf("find participant ids: %w", err)
+	}
+
+	return publishEvent(ctx, s.eventBus, topic, participantIDs, message)
+}
+
+func (r *MessageRepository) findParticipantIDs(
+	ctx context.Context,
+	conversationID entity.ID,
+) ([]entity.ID, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT user_id FROM participants WHERE conversation_id = $1`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []entity.ID
+
+	for rows.Next() {
+		var id entity.ID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
 }
 
 func (r *MessageRepository) FindConversationIDsFromUserIDs(ctx context.Context,
 	inputs []entity.RelayQueryInput) (map[entity.ID]*entity.IDsConnection, error) {
-	// TODO: find a better solution
-	res := make(map[entity.ID]*entity.IDsConnection)
+	pages := make([]keyedPage, 0, len(inputs))
 	for _, input := range inputs {
-		idsConnection, err := r.getConversationIDsFromUserID(ctx, input)
+		if !entity.IsValidConversationsSortByType(string(input.SortBy)) {
+			return nil, fmt.Errorf("invalid sortBy: %v", input.SortBy)
+		}
+
+		k := keyset{
+			from:     "participants AS p INNER JOIN conversations AS c ON c.id = p.conversation_id",
+			where:    "p.user_id = k.key_id AND c.deleted_at IS NULL",
+			idColumn: "c.id",
+			sortColumn: "c." + model.GetColumnNameByConversationsSortByType(
+				entity.ConversationsSortByType(input.SortBy)),
+			sortType:  "TIMESTAMPTZ",
+			sortOrder: input.SortOrder,
+		}
+
+		p, err := newPage(input.ListQueryInput, k, r.cursorCodec)
 		if err != nil {
-			return nil, fmt.Errorf("get conversation ids from user: %w", err)
+			return nil, err
 		}
 
-		res[input.KeyID] = idsConnection
+		pages = append(pages, keyedPage{keyset: k, key: input.KeyID, page: p})
+	}
+
+	connections, err := findIDsConnections(ctx, r.db, r.cursorCodec, pages)
+	if err != nil {
+		return nil, fmt.Errorf("find conversation ids: %w", err)
+	}
+
+	res := make(map[entity.ID]*entity.IDsConnection)
+	for i, input := range inputs {
+		res[input.KeyID] = connections[i]
 	}
 
 	return res, nil
 }
 
-func (r *MessageRepository) getConversationIDsFromUserID(ctx context.Context,
-	input entity.RelayQueryInput) (*entity.IDsConnection, error) {
-	if !entity.IsValidConversationsSortByType(string(input.SortBy)) {
-		return nil, fmt.Errorf("invalid sortBy: %v", input.SortBy)
+// FindMessagesInConversations pages the messages of each conversation, thread
+// replies are left to FindRepliesOfMessages.
+func (r *MessageRepository) FindMessagesInConversations(ctx context.Context,
+	inputs []entity.RelayQueryInput) (map[entity.ID]*entity.ConversationMessagesConnection, error) {
+	res, err := r.findMessagesConnections(ctx, inputs,
+		"conversation_id = k.key_id AND parent_id IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("FindMessagesInConversations: %w", err)
 	}
 
-	sortColumn := model.GetColumnNameByConversationsSortByType(
-		entity.ConversationsSortByType(input.SortBy))
-	var (
-		query string
-		rows  *sql.Rows
-		err   error
-	)
+	return res, nil
+}
+
+// FindRepliesOfMessages pages the replies in the thread of each message.
+func (r *MessageRepository) FindRepliesOfMessages(ctx context.Context,
+	inputs []entity.RelayQueryInput) (map[entity.ID]*entity.ConversationMessagesConnection, error) {
+	res, err := r.findMessagesConnections(ctx, inputs, "parent_id = k.key_id")
+	if err != nil {
+		return nil, fmt.Errorf("FindRepliesOfMessages: %w", err)
+	}
+
+	return res, nil
+}
+
+// findMessagesConnections pages the messages selected by where for each key.
+func (r *MessageRepository) findMessagesConnections(ctx context.Context,
+	inputs []entity.RelayQueryInput, where string,
+) (map[entity.ID]*entity.ConversationMessagesConnection, error) {
+	pages := make([]keyedPage, 0, len(inputs))
+	for _, input := range inputs {
+		if !entity.IsValidMessagesSortByType(string(input.SortBy)) {
+			return nil, fmt.Errorf("invalid sortBy: %v", input.SortBy)
+		}
 
-	if input.After == 0 {
-		query =
-			"SELECT conversation_id, " +
-				"FALSE AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM participants " +
-				"   WHERE user_id = $1 " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2 + 1 " +
-				"  ) as np " +
-				" ) = $2 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM participants " +
-				"WHERE user_id = $1 " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2"
-
-		rows, err = r.db.QueryContext(ctx, query, input.KeyID, input.First)
-	} else {
-		query =
-			"SELECT id, " +
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) FROM participants " +
-				"   WHERE " + sortColumn + " <= (SELECT " + sortColumn + " FROM participants WHERE id = $3) " +
-				"   AND id != $3 " +
-				"   AND id NOT IN " +
-				"    (SELECT id FROM participants " +
-				"      WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM participants WHERE id = $3 ) " +
-				"      AND id >= $3 ) " +
-				"   AND user_id = $1 " +
-				" ) > 0 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM participants " +
-				"   WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM participants WHERE id = $3 ) " +
-				"   AND id != $3 " +
-				"   AND user_id = $1 " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2 + 1 " +
-				"  ) as np " +
-				" ) = $2 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM participants " +
-				"WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM participants WHERE id = $3 ) " +
-				"AND id != $3 " +
-				"AND id NOT IN ( " +
-				" SELECT id FROM participants " +
-				" WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM participants WHERE id = $3 ) " +
-				" AND id <= $3 " +
-				") " +
-				"AND user_id = $1 " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2"
-
-		rows, err = r.db.QueryContext(ctx, query, input.KeyID, input.First, input.After)
+		k := keyset{
+			from:     "messages",
+			where:    where,
+			idColumn: "id",
+			sortColumn: model.GetColumnNameByMessagesSortByType(
+				entity.MessagesSortByType(input.SortBy)),
+			sortType:  "TIMESTAMPTZ",
+			sortOrder: input.SortOrder,
+		}
+
+		p, err := newPage(input.ListQueryInput, k, r.cursorCodec)
+		if err != nil {
+			return nil, err
+		}
+
+		pages = append(pages, keyedPage{keyset: k, key: input.KeyID, page: p})
 	}
 
+	query, args, err := batchQuery(
+		"conversation_id, sender_id, type, content, created_at, updated_at, edited_at, deleted_at, "+
+			"parent_id, reply_count, last_reply_at",
+		pages)
 	if err != nil {
 		return nil, err
 	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("find messages: %w", err)
+	}
 	defer rows.Close()
 
-	var (
-		idEdges         []*entity.IDsEdge
-		hasNextPage     bool
-		hasPreviousPage bool
-	)
+	connections := make([]*entity.ConversationMessagesConnection, len(pages))
+	for i := range connections {
+		connections[i] = &entity.ConversationMessagesConnection{}
+	}
+
+	hasMoreBehind := make([]bool, len(pages))
 
 	for rows.Next() {
-		var edge struct {
-			ID              entity.ID `json:"id"`
-			HasNextPage     bool      `json:"has_next_page"`
-			HasPreviousPage bool      `json:"has_previous_page"`
-		}
+		var (
+			row            batchRow
+			conversationID *entity.ID
+			senderID       *entity.ID
+			messageType    *entity.MessageType
+			content        *string
+			createdAt      *time.Time
+			updatedAt      *time.Time
+			editedAt       *time.Time
+			deletedAt      *time.Time
+			parentID       *entity.ID
+			replyCount     *int
+			lastReplyAt    *time.Time
+		)
 
-		if err := rows.Scan(
-			&edge.ID,
-			&edge.HasNextPage,
-			&edge.HasPreviousPage,
-		); err != nil {
+		if err := rows.Scan(row.dest(
+			&conversationID,
+			&senderID,
+			&messageType,
+			&content,
+			&createdAt,
+			&updatedAt,
+			&editedAt,
+			&deletedAt,
+			&parentID,
+			&replyCount,
+			&lastReplyAt,
+		)...); err != nil {
 			return nil, err
 		}
 
-		hasNextPage = edge.HasNextPage
-		hasPreviousPage = edge.HasPreviousPage
-		idEdges = append(idEdges, &entity.IDsEdge{
-			Node:   edge.ID,
-			Cursor: edge.ID,
+		connection := connections[row.ord]
+		connection.TotalCount = row.totalCount
+		hasMoreBehind[row.ord] = row.hasMoreBehind
+
+		if row.empty() {
+			continue
+		}
+
+		connection.Edges = append(connection.Edges, &entity.ConversationMessagesEdge{
+			Node: model.ConvertModelMessage(&model.Message{
+				ID:             *row.id,
+				ConversationID: *conversationID,
+				SenderID:       *senderID,
+				Type:           *messageType,
+				Content:        *content,
+				CreatedAt:      *createdAt,
+				UpdatedAt:      *updatedAt,
+				EditedAt:       editedAt,
+				DeletedAt:      deletedAt,
+				ParentID:       parentID,
+				ReplyCount:     *replyCount,
+				LastReplyAt:    lastReplyAt,
+			}),
+			Cursor: row.cursor(r.cursorCodec, pages[row.ord].keyset),
 		})
 	}
 
@@ -588,164 +829,232 @@ func (r *MessageRepository) getConversationIDsFromUserID(ctx context.Context,
 		return nil, err
 	}
 
-	return &entity.IDsConnection{
-		Edges: idEdges,
-		PageInfo: &entity.PageInfo{
-			HasPreviousPage: hasPreviousPage,
-			HasNextPage:     hasNextPage,
-		},
-		TotalCount: len(idEdges),
-	}, nil
+	res := make(map[entity.ID]*entity.ConversationMessagesConnection)
+	for i, connection := range connections {
+		p := pages[i].page
+
+		length, hasMore := p.trim(connection.Edges)
+		connection.Edges = connection.Edges[:length]
+
+		var startCursor, endCursor *entity.Cursor
+		if length > 0 {
+			startCursor, endCursor = &connection.Edges[0].Cursor, &connection.Edges[length-1].Cursor
+		}
+
+		connection.PageInfo = p.pageInfo(hasMore, hasMoreBehind[i], startCursor, endCursor)
+		res[inputs[i].KeyID] = connection
+	}
+
+	return res, nil
 }
 
-func (r *MessageRepository) FindMessagesInConversations(ctx context.Context,
-	inputs []entity.RelayQueryInput) (map[entity.ID]*entity.ConversationMessagesConnection, error) {
-	// TODO: find a better solution
-	res := make(map[entity.ID]*entity.ConversationMessagesConnection)
-	for _, input := range inputs {
-		cmc, err := r.findMessagesInConversation(ctx, input)
+func (r *MessageRepository) FindMessagesByIDs(
+	ctx context.Context,
+	messageIDs []entity.ID,
+) ([]*entity.Message, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+messageColumns+`
+		 FROM messages
+		 WHERE id = ANY($1)`,
+		pq.Array(messageIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindMessagesByIDs: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*entity.Message
+
+	for rows.Next() {
+		message, err := scanMessage(rows)
 		if err != nil {
-			return nil, fmt.Errorf("find conversation ids from user: %w", err)
+			return nil, fmt.Errorf("FindMessagesByIDs: %w", err)
 		}
 
-		res[input.KeyID] = cmc
+		messages = append(messages, model.ConvertModelMessage(message))
 	}
 
-	return res, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindMessagesByIDs: %w", err)
+	}
+
+	return messages, nil
 }
 
-func (r *MessageRepository) findMessagesInConversation(ctx context.Context,
-	input entity.RelayQueryInput) (*entity.ConversationMessagesConnection, error) {
-	if !entity.IsValidMessagesSortByType(string(input.SortBy)) {
-		return nil, fmt.Errorf("invalid sortBy: %v", input.SortBy)
+// FindMessageWithTransaction returns the message locked until the end of the
+// transaction.
+func (r *MessageRepository) FindMessageWithTransaction(
+	ctx context.Context,
+	messageID entity.ID,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("FindMessageWithTransaction: %w", err)
 	}
 
-	sortColumn := model.GetColumnNameByMessagesSortByType(
-		entity.MessagesSortByType(input.SortBy))
-	var (
-		query string
-		rows  *sql.Rows
-		err   error
-	)
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
 
-	if input.After == 0 {
-		query =
-			"SELECT id, conversation_id, sender_id, type, content, created_at, updated_at, deleted_at, " +
-				"FALSE AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM messages " +
-				"   WHERE conversation_id = $1 " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2 + 1 " +
-				"  ) as np " +
-				" ) = $2 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM messages " +
-				"WHERE conversation_id = $1 " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2"
-
-		rows, err = r.db.QueryContext(ctx, query, input.KeyID, input.First)
-	} else {
-		query =
-			"SELECT id, conversation_id, sender_id, type, content, created_at, updated_at, deleted_at, " +
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) FROM messages " +
-				"   WHERE " + sortColumn + " <= (SELECT " + sortColumn + " FROM messages WHERE id = $3) " +
-				"   AND id != $3 " +
-				"   AND id NOT IN " +
-				"    (SELECT id FROM messages " +
-				"      WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM messages WHERE id = $3 ) " +
-				"      AND id >= $3 ) " +
-				"   AND conversation_id = $1 " +
-				" ) > 0 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM messages " +
-				"   WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM messages WHERE id = $3 ) " +
-				"   AND id != $3 " +
-				"   AND conversation_id = $1 " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2 + 1 " +
-				"  ) as np " +
-				" ) = $2 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM messages " +
-				"WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM messages WHERE id = $3 ) " +
-				"AND id != $3 " +
-				"AND id NOT IN ( " +
-				" SELECT id FROM messages " +
-				" WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM messages WHERE id = $3 ) " +
-				" AND id <= $3 " +
-				") " +
-				"AND conversation_id = $1 " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $2"
-
-		rows, err = r.db.QueryContext(ctx, query, input.KeyID, input.First, input.After)
+	message, err := scanMessage(tx.QueryRowContext(
+		ctx,
+		`SELECT `+messageColumns+`
+		 FROM messages
+		 WHERE id = $1
+		 FOR UPDATE`,
+		messageID,
+	))
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fail(fmt.Errorf("message %v: %w", messageID, domainerrors.ErrNotFound))
+	case err != nil:
+		return fail(err)
+	default:
+		return model.ConvertModelMessage(message), nil
 	}
+}
 
+// EditMessageWithTransaction replaces the content of the message and keeps
+// the previous one as a revision.
+func (r *MessageRepository) EditMessageWithTransaction(
+	ctx context.Context,
+	messageID entity.ID,
+	content string,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("EditMessageWithTransaction: %w", err)
+	}
+
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	message, err := scanMessage(tx.QueryRowContext(
+		ctx,
+		`WITH revision AS (
+			INSERT INTO message_revisions(message_id, content, created_at)
+			SELECT id, content, COALESCE(edited_at, created_at)
+			  FROM messages
+			 WHERE id = $1
+		)
+		UPDATE messages
+		   SET content = $2, edited_at = NOW(), updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING `+messageColumns,
+		messageID, content,
+	))
 	if err != nil {
-		return nil, err
+		return fail(err)
+	}
+
+	return model.ConvertModelMessage(message), nil
+}
+
+// DeleteMessageWithTransaction turns the message into a tombstone, its
+// content is kept but never read back. A deleted reply no longer counts in
+// the thread of its parent.
+func (r *MessageRepository) DeleteMessageWithTransaction(
+	ctx context.Context,
+	messageID entity.ID,
+) (*entity.Message, error) {
+	fail := func(err error) (*entity.Message, error) {
+		return nil, fmt.Errorf("DeleteMessageWithTransaction: %w", err)
 	}
-	defer rows.Close()
 
-	var (
-		cmEdges         []*entity.ConversationMessagesEdge
-		hasNextPage     bool
-		hasPreviousPage bool
+	tx, ok := r.dbTransactor.GetTransactionFromCtx(ctx)
+	if !ok {
+		return fail(fmt.Errorf("get transaction from ctx failed"))
+	}
+
+	message, err := scanMessage(tx.QueryRowContext(
+		ctx,
+		`WITH message AS (
+			UPDATE messages
+			   SET deleted_at = NOW(), updated_at = NOW()
+			 WHERE id = $1
+			 RETURNING `+messageColumns+`
+		), thread AS (
+			UPDATE messages AS p
+			   SET reply_count = p.reply_count - 1,
+			       last_reply_at = (
+			         SELECT MAX(r.created_at)
+			           FROM messages AS r
+			          WHERE r.parent_id = p.id AND r.deleted_at IS NULL AND r.id <> m.id
+			       )
+			  FROM message AS m
+			 WHERE p.id = m.parent_id
+		)
+		SELECT `+messageColumns+` FROM message`,
+		messageID,
+	))
+	if err != nil {
+		return fail(err)
+	}
+
+	return model.ConvertModelMessage(message), nil
+}
+
+func (s *MessageRepository) MessageDeleted(
+	ctx context.Context,
+	input entity.User,
+	conversationID *entity.ID,
+) (<-chan *entity.Message, error) {
+	return s.subscribeMessages(ctx, s.deleteSubscriptions, input.ID, conversationID), nil
+}
+
+func (s *MessageRepository) FanoutMessageDeletion(
+	ctx context.Context,
+	message *entity.Message,
+) error {
+	if err := s.fanoutMessage(ctx, topicMessageDeleted, message); err != nil {
+		return fmt.Errorf("FanoutMessageDeletion: %w", err)
+	}
+
+	return nil
+}
+
+// FindMessageRevisions returns the revisions of each message, oldest first.
+// Deleted messages have no revisions.
+func (r *MessageRepository) FindMessageRevisions(
+	ctx context.Context,
+	messageIDs []entity.ID,
+) (map[entity.ID][]*entity.MessageRevision, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT r.id, r.message_id, r.content, r.created_at
+		 FROM message_revisions AS r
+		 INNER JOIN messages AS m ON m.id = r.message_id
+		 WHERE r.message_id = ANY($1) AND m.deleted_at IS NULL
+		 ORDER BY r.created_at ASC, r.id ASC`,
+		pq.Array(messageIDs),
 	)
+	if err != nil {
+		return nil, fmt.Errorf("FindMessageRevisions: %w", err)
+	}
+	defer rows.Close()
 
-	for rows.Next() {
-		var edge struct {
-			Message         entity.Message
-			HasNextPage     bool `json:"has_next_page"`
-			HasPreviousPage bool `json:"has_previous_page"`
-		}
+	res := make(map[entity.ID][]*entity.MessageRevision)
 
+	for rows.Next() {
+		var revision entity.MessageRevision
 		if err := rows.Scan(
-			&edge.Message.ID,
-			&edge.Message.ConversationID,
-			&edge.Message.SenderID,
-			&edge.Message.Type,
-			&edge.Message.Content,
-			&edge.Message.CreatedAt,
-			&edge.Message.UpdatedAt,
-			&edge.Message.DeletedAt,
-			&edge.HasNextPage,
-			&edge.HasPreviousPage,
+			&revision.ID,
+			&revision.MessageID,
+			&revision.Content,
+			&revision.CreatedAt,
 		); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("FindMessageRevisions: %w", err)
 		}
 
-		hasNextPage = edge.HasNextPage
-		hasPreviousPage = edge.HasPreviousPage
-		cmEdges = append(cmEdges, &entity.ConversationMessagesEdge{
-			Node:   &edge.Message,
-			Cursor: edge.Message.ID,
-		})
+		res[revision.MessageID] = append(res[revision.MessageID], &revision)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("FindMessageRevisions: %w", err)
 	}
 
-	return &entity.ConversationMessagesConnection{
-		Edges: cmEdges,
-		PageInfo: &entity.PageInfo{
-			HasPreviousPage: hasPreviousPage,
-			HasNextPage:     hasNextPage,
-		},
-		TotalCount: len(cmEdges),
-	}, nil
+	return res, nil
 }
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/model"
+)
+
+const (
+	// searchConfig is the text search configuration of messages, it does not
+	// stem nor drop stop words since messages are in any language.
+	searchConfig = "simple"
+	// searchHeadlineOptions makes snippets of up to two fragments around the
+	// matches.
+	searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, " +
+		"MinWords=10, MaxWords=30, MaxFragments=2, FragmentDelimiter=\" … \""
+)
+
+// SearchMessages pages the messages matching the query in the conversations
+// the viewer participates in. The query follows the web search syntax, e.g.
+// quoted phrases, OR and -word.
+func (r *MessageRepository) SearchMessages(
+	ctx context.Context,
+	viewerID entity.ID,
+	query entity.MessageSearchQuery,
+) (*entity.MessageSearchConnection, error) {
+	fail := func(err error) (*entity.MessageSearchConnection, error) {
+		return nil, fmt.Errorf("SearchMessages: %w", err)
+	}
+
+	if !entity.IsValidSearchMessagesSortByType(query.SortBy) {
+		return fail(fmt.Errorf("invalid sortBy: %v", query.SortBy))
+	}
+
+	sortType := "TIMESTAMPTZ"
+	if entity.SearchMessagesSortByType(query.SortBy) == entity.SearchMessagesSortByTypeRelevance {
+		sortType = "REAL"
+	}
+
+	// batchQuery binds the five arrays of the only page first, the filters
+	// come after them
+	k := keyset{
+		from: fmt.Sprintf("messages CROSS JOIN websearch_to_tsquery('%s', CAST($6 AS TEXT)) AS q(query)",
+			searchConfig),
+		where: `search_vector @@ q.query AND deleted_at IS NULL
+			AND conversation_id IN (SELECT p.conversation_id FROM participants AS p
+				INNER JOIN conversations AS c ON c.id = p.conversation_id
+				WHERE p.user_id = k.key_id AND c.deleted_at IS NULL)
+			AND (CAST($7 AS INTEGER) IS NULL OR conversation_id = CAST($7 AS INTEGER))
+			AND (CAST($8 AS INTEGER) IS NULL OR sender_id = CAST($8 AS INTEGER))
+			AND (CAST($9 AS TIMESTAMPTZ) IS NULL OR created_at < CAST($9 AS TIMESTAMPTZ))
+			AND (CAST($10 AS TIMESTAMPTZ) IS NULL OR created_at > CAST($10 AS TIMESTAMPTZ))`,
+		idColumn: "id",
+		sortColumn: model.GetColumnNameBySearchMessagesSortByType(
+			entity.SearchMessagesSortByType(query.SortBy)),
+		sortType:  sortType,
+		sortOrder: query.SortOrder,
+	}
+
+	p, err := newPage(query.ListQueryInput, k, r.cursorCodec)
+	if err != nil {
+		return fail(err)
+	}
+
+	pages := []keyedPage{{keyset: k, key: viewerID, page: p}}
+
+	statement, args, err := batchQuery(fmt.Sprintf(
+		"conversation_id, sender_id, type, content, created_at, updated_at, edited_at, deleted_at, "+
+			"parent_id, reply_count, last_reply_at, "+
+			"ts_headline('%s', replace(replace(replace(content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), "+
+			"q.query, '%s') AS snippet",
+		searchConfig, searchHeadlineOptions), pages)
+	if err != nil {
+		return fail(err)
+	}
+
+	args = append(args, query.Text, query.ConversationID, query.SenderID,
+		query.SentBefore, query.SentAfter)
+
+	rows, err := r.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return fail(fmt.Errorf("search messages: %w", err))
+	}
+	defer rows.Close()
+
+	var (
+		connection    = &entity.MessageSearchConnection{}
+		hasMoreBehind bool
+	)
+
+	for rows.Next() {
+		var (
+			row            batchRow
+			conversationID *entity.ID
+			senderID       *entity.ID
+			messageType    *entity.MessageType
+			content        *string
+			createdAt      *time.Time
+			updatedAt      *time.Time
+			editedAt       *time.Time
+			deletedAt      *time.Time
+			parentID       *entity.ID
+			replyCount     *int
+			lastReplyAt    *time.Time
+			snippet        *string
+		)
+
+		if err := rows.Scan(row.dest(
+			&conversationID,
+			&senderID,
+			&messageType,
+			&content,
+			&createdAt,
+			&updatedAt,
+			&editedAt,
+			&deletedAt,
+			&parentID,
+			&replyCount,
+			&lastReplyAt,
+			&snippet,
+		)...); err != nil {
+			return fail(err)
+		}
+
+		connection.TotalCount = row.totalCount
+		hasMoreBehind = row.hasMoreBehind
+
+		if row.empty() {
+			continue
+		}
+
+		connection.Edges = append(connection.Edges, &entity.MessageSearchEdge{
+			Node: model.ConvertModelMessage(&model.Message{
+				ID:             *row.id,
+				ConversationID: *conversationID,
+				SenderID:       *senderID,
+				Type:           *messageType,
+				Content:        *content,
+				CreatedAt:      *createdAt,
+				UpdatedAt:      *updatedAt,
+				EditedAt:       editedAt,
+				DeletedAt:      deletedAt,
+				ParentID:       parentID,
+				ReplyCount:     *replyCount,
+				LastReplyAt:    lastReplyAt,
+			}),
+			Cursor:  row.cursor(r.cursorCodec, k),
+			Snippet: *snippet,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return fail(err)
+	}
+
+	length, hasMore := p.trim(connection.Edges)
+	connection.Edges = connection.Edges[:length]
+
+	var startCursor, endCursor *entity.Cursor
+	if length > 0 {
+		startCursor, endCursor = &connection.Edges[0].Cursor, &connection.Edges[length-1].Cursor
+	}
+
+	connection.PageInfo = p.pageInfo(hasMore, hasMoreBehind, startCursor, endCursor)
+
+	return connection, nil
+}
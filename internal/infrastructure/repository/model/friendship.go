@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+// Friendship model
+type Friendship struct {
+	UserID    entity.ID `json:"user_id"`
+	FriendID  entity.ID `json:"friend_id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ConvertModelFriendship(input *Friendship) *entity.Friendship {
+	if input == nil {
+		return nil
+	}
+
+	return &entity.Friendship{
+		UserID:    input.UserID,
+		FriendID:  input.FriendID,
+		Status:    entity.FriendshipStatus(input.Status),
+		CreatedAt: input.CreatedAt,
+		UpdatedAt: input.UpdatedAt,
+	}
+}
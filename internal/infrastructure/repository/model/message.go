@@ -15,23 +15,38 @@ type Message struct {
 	Content        string             `json:"content"`
 	CreatedAt      time.Time          `json:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at"`
+	EditedAt       *time.Time         `json:"edited_at"`
 	DeletedAt      *time.Time         `json:"deleted_at"`
+	ParentID       *entity.ID         `json:"parent_id"`
+	ReplyCount     int                `json:"reply_count"`
+	LastReplyAt    *time.Time         `json:"last_reply_at"`
 }
 
+// ConvertModelMessage converts a message row, the content of a deleted message
+// is redacted so that only its tombstone leaves the repository.
 func ConvertModelMessage(msg *Message) *entity.Message {
 	if msg == nil {
 		return nil
 	}
 
+	content := msg.Content
+	if msg.DeletedAt != nil {
+		content = ""
+	}
+
 	return &entity.Message{
 		ID:             msg.ID,
 		ConversationID: msg.ConversationID,
 		SenderID:       msg.SenderID,
 		Type:           msg.Type,
-		Content:        msg.Content,
+		Content:        content,
 		CreatedAt:      msg.CreatedAt,
 		UpdatedAt:      msg.UpdatedAt,
+		EditedAt:       msg.EditedAt,
 		DeletedAt:      msg.DeletedAt,
+		ParentID:       msg.ParentID,
+		ReplyCount:     msg.ReplyCount,
+		LastReplyAt:    msg.LastReplyAt,
 	}
 }
 
@@ -48,6 +63,19 @@ func ConvertModelMessages(msgs []*Message) []*entity.Message {
 	return mm
 }
 
+// GetColumnNameBySearchMessagesSortByType returns the sort expression of a
+// search, the matched query is bound as q.query.
+func GetColumnNameBySearchMessagesSortByType(t entity.SearchMessagesSortByType) string {
+	switch t {
+	case entity.SearchMessagesSortByTypeRelevance:
+		return "ts_rank(search_vector, q.query)"
+	case entity.SearchMessagesSortByTypeCreatedAt:
+		return "created_at"
+	default:
+		return ""
+	}
+}
+
 func GetColumnNameByMessagesSortByType(t entity.MessagesSortByType) string {
 	switch t {
 	case entity.MessagesSortByTypeCreatedAt:
@@ -11,6 +11,22 @@ type Participant struct {
 	ID             entity.ID `json:"id"`
 	ConversationID entity.ID `json:"conversation_id"`
 	UserID         entity.ID `json:"user_id"`
+	Role           string    `json:"role"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
+
+func ConvertModelParticipant(input *Participant) *entity.Participant {
+	if input == nil {
+		return nil
+	}
+
+	return &entity.Participant{
+		ID:             input.ID,
+		ConversationID: input.ConversationID,
+		UserID:         input.UserID,
+		Role:           entity.ParticipantRole(input.Role),
+		CreatedAt:      input.CreatedAt,
+		UpdatedAt:      input.UpdatedAt,
+	}
+}
@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// presenceKeyPrefix holds per user a hash of the live connections.
+	presenceKeyPrefix = "presence:connections:"
+	// lastSeenKeyPrefix holds per user the unix time they were last seen.
+	lastSeenKeyPrefix = "presence:last_seen:"
+	// presenceExpiryKey scores every connected user by the time their
+	// connections expire unless refreshed.
+	presenceExpiryKey = "presence:expiry"
+	// presenceTTL is how long a connection counts as live without being
+	// refreshed, it bounds how long a user of a dead process looks online.
+	presenceTTL = time.Minute
+)
+
+// connectionRecord is what is kept per connection of a user.
+type connectionRecord struct {
+	Status    entity.PresenceStatus `json:"status"`
+	ExpiresAt int64                 `json:"expires_at"`
+}
+
+type PresenceRepository struct {
+	cacher                external.Cacher
+	eventBus              external.EventBus
+	presenceSubscriptions *subscription.Registry
+	db                    *sql.DB
+}
+
+func NewPresenceRepository(
+	cacher external.Cacher,
+	eventBus external.EventBus,
+	db *sql.DB,
+	subscriptionOption subscription.Option,
+) *PresenceRepository {
+	r := &PresenceRepository{
+		cacher:                cacher,
+		eventBus:              eventBus,
+		db:                    db,
+		presenceSubscriptions: subscription.NewRegistry(topicPresenceChanged, subscriptionOption),
+	}
+
+	listenEvents(eventBus, topicPresenceChanged, r.presenceSubscriptions,
+		func(payload json.RawMessage) (interface{}, error) {
+			var presence entity.Presence
+			if err := json.Unmarshal(payload, &presence); err != nil {
+				return nil, err
+			}
+
+			return subscription.Keyed(presence.UserID.String(), &presence), nil
+		})
+
+	return r
+}
+
+// Connect records a new connection of the user and returns its id.
+func (r *PresenceRepository) Connect(
+	ctx context.Context,
+	userID entity.ID,
+	status entity.PresenceStatus,
+) (string, error) {
+	connectionID := ksuid.New().String()
+
+	if err := r.RefreshConnection(ctx, userID, connectionID, status); err != nil {
+		return "", fmt.Errorf("Connect: %w", err)
+	}
+
+	return connectionID, nil
+}
+
+// RefreshConnection keeps the connection live for another presenceTTL.
+func (r *PresenceRepository) RefreshConnection(
+	ctx context.Context,
+	userID entity.ID,
+	connectionID string,
+	status entity.PresenceStatus,
+) error {
+	fail := func(err error) error {
+		return fmt.Errorf("RefreshConnection: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(presenceTTL).Unix()
+
+	record, err := json.Marshal(&connectionRecord{Status: status, ExpiresAt: expiresAt})
+	if err != nil {
+		return fail(fmt.Errorf("marshal record: %w", err))
+	}
+
+	if err := r.cacher.HSetWithTTL(presenceKey(userID), connectionID, record,
+		presenceTTL); err != nil {
+		return fail(err)
+	}
+
+	if err := r.cacher.ZAdd(presenceExpiryKey, float64(expiresAt),
+		userID.String()); err != nil {
+		return fail(err)
+	}
+
+	if err := r.touch(userID, now); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+// Disconnect forgets the connection of the user.
+func (r *PresenceRepository) Disconnect(
+	ctx context.Context,
+	userID entity.ID,
+	connectionID string,
+) error {
+	if err := r.cacher.HDel(presenceKey(userID), connectionID); err != nil {
+		return fmt.Errorf("Disconnect: %w", err)
+	}
+
+	if err := r.touch(userID, time.Now()); err != nil {
+		return fmt.Errorf("Disconnect: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PresenceRepository) touch(userID entity.ID, seenAt time.Time) error {
+	return r.cacher.Set(lastSeenKeyPrefix+userID.String(),
+		[]byte(strconv.FormatInt(seenAt.Unix(), 10)))
+}
+
+// FindPresences returns the presence of every user. A user is online when
+// one of their live connections is, away when all of them are away and
+// offline without any.
+func (r *PresenceRepository) FindPresences(
+	ctx context.Context,
+	userIDs []entity.ID,
+) (map[entity.ID]*entity.Presence, error) {
+	res := make(map[entity.ID]*entity.Presence, len(userIDs))
+	if len(userIDs) == 0 {
+		return res, nil
+	}
+
+	connectionKeys := make([]string, 0, len(userIDs))
+	lastSeenKeys := make([]string, 0, len(userIDs))
+
+	for _, id := range userIDs {
+		connectionKeys = append(connectionKeys, presenceKey(id))
+		lastSeenKeys = append(lastSeenKeys, lastSeenKeyPrefix+id.String())
+	}
+
+	connections, err := r.cacher.HGetAll(connectionKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("FindPresences: %w", err)
+	}
+
+	lastSeen, err := r.cacher.MGet(lastSeenKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("FindPresences: %w", err)
+	}
+
+	now := time.Now().Unix()
+
+	for i, id := range userIDs {
+		presence := &entity.Presence{
+			UserID: id,
+			Status: entity.PresenceStatusOffline,
+		}
+
+		for _, raw := range connections[i] {
+			var record connectionRecord
+			if err := json.Unmarshal([]byte(raw), &record); err != nil ||
+				record.ExpiresAt < now {
+				continue
+			}
+
+			if presence.Status != entity.PresenceStatusOnline {
+				presence.Status = record.Status
+			}
+		}
+
+		if lastSeen[i] != nil {
+			if unix, err := strconv.ParseInt(*lastSeen[i], 10, 64); err == nil {
+				t := time.Unix(unix, 0)
+				presence.LastSeenAt = &t
+			}
+		}
+
+		res[id] = presence
+	}
+
+	return res, nil
+}
+
+// FindExpiredUserIDs returns the users whose connections were all due to be
+// refreshed by now, e.g. because the process holding them died.
+func (r *PresenceRepository) FindExpiredUserIDs(ctx context.Context) ([]entity.ID, error) {
+	members, err := r.cacher.ZRangeByScore(presenceExpiryKey, float64(time.Now().Unix()))
+	if err != nil {
+		return nil, fmt.Errorf("FindExpiredUserIDs: %w", err)
+	}
+
+	ids := make([]entity.ID, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, entity.ID(id))
+	}
+
+	return ids, nil
+}
+
+// ReleaseExpiredUser stops tracking the expiry of the user, it reports false
+// when another caller did it first.
+func (r *PresenceRepository) ReleaseExpiredUser(ctx context.Context,
+	userID entity.ID) (bool, error) {
+	released, err := r.cacher.ZRem(presenceExpiryKey, userID.String())
+	if err != nil {
+		return false, fmt.Errorf("ReleaseExpiredUser: %w", err)
+	}
+
+	return released, nil
+}
+
+func (r *PresenceRepository) PresenceChanged(
+	ctx context.Context,
+	input entity.User,
+) (<-chan *entity.Presence, error) {
+	presences := make(chan *entity.Presence, 1)
+
+	r.presenceSubscriptions.Subscribe(ctx, input.ID,
+		func(ctx context.Context, event interface{}) {
+			presence, ok := event.(*entity.Presence)
+			if !ok {
+				return
+			}
+
+			select {
+			case presences <- presence:
+			case <-ctx.Done():
+			}
+		},
+		func() { close(presences) },
+	)
+
+	return presences, nil
+}
+
+// FanoutPresence publishes the presence to the user, their friends and the
+// participants of their conversations.
+func (r *PresenceRepository) FanoutPresence(
+	ctx context.Context,
+	presence *entity.Presence,
+) error {
+	audienceIDs, err := r.findAudienceIDs(ctx, presence.UserID)
+	if err != nil {
+		return fmt.Errorf("FanoutPresence: find audience ids: %w", err)
+	}
+
+	if err := publishEvent(ctx, r.eventBus, topicPresenceChanged, audienceIDs,
+		presence); err != nil {
+		return fmt.Errorf("FanoutPresence: %w", err)
+	}
+
+	return nil
+}
+
+// FindUserIDsSeenBy returns the users among userIDs whose presence the
+// viewer is in the audience of, the same one FanoutPresence publishes to.
+func (r *PresenceRepository) FindUserIDsSeenBy(
+	ctx context.Context,
+	viewerID entity.ID,
+	userIDs []entity.ID,
+) ([]entity.ID, error) {
+	fail := func(err error) ([]entity.ID, error) {
+		return nil, fmt.Errorf("FindUserIDsSeenBy: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT u.id
+		 FROM unnest(CAST($2 AS INTEGER[])) AS u(id)
+		 WHERE u.id = $1
+		    OR EXISTS (
+		      SELECT 1 FROM friendships
+		       WHERE user_id = u.id AND friend_id = $1 AND status = $3
+		    )
+		    OR EXISTS (
+		      SELECT 1
+		        FROM participants AS self
+		        INNER JOIN participants AS other ON other.conversation_id = self.conversation_id
+		       WHERE self.user_id = u.id AND other.user_id = $1
+		    )`,
+		viewerID, pq.Array(userIDs), entity.FriendshipStatusAccepted,
+	)
+	if err != nil {
+		return fail(err)
+	}
+	defer rows.Close()
+
+	var ids []entity.ID
+
+	for rows.Next() {
+		var id entity.ID
+		if err := rows.Scan(&id); err != nil {
+			return fail(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fail(err)
+	}
+
+	return ids, nil
+}
+
+func (r *PresenceRepository) findAudienceIDs(
+	ctx context.Context,
+	userID entity.ID,
+) ([]entity.ID, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT CAST($1 AS INTEGER)
+		 UNION
+		 SELECT friend_id FROM friendships WHERE user_id = $1 AND status = $2
+		 UNION
+		 SELECT other.user_id
+		 FROM participants AS self
+		 INNER JOIN participants AS other ON other.conversation_id = self.conversation_id
+		 WHERE self.user_id = $1`,
+		userID, entity.FriendshipStatusAccepted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []entity.ID
+
+	for rows.Next() {
+		var id entity.ID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func presenceKey(userID entity.ID) string {
+	return presenceKeyPrefix + userID.String()
+}
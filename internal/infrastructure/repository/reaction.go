@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+// AddReaction puts the emoji of the user on the message, it reports false
+// when the user had already reacted with it.
+func (r *MessageRepository) AddReaction(
+	ctx context.Context,
+	messageID entity.ID,
+	userID entity.ID,
+	emoji string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO reactions(message_id, user_id, emoji)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, message_id, emoji) DO NOTHING`,
+		messageID, userID, emoji,
+	)
+	if err != nil {
+		return false, fmt.Errorf("AddReaction: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("AddReaction: rows affected: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// RemoveReaction takes the emoji of the user off the message, it reports
+// false when the user had not reacted with it.
+func (r *MessageRepository) RemoveReaction(
+	ctx context.Context,
+	messageID entity.ID,
+	userID entity.ID,
+	emoji string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM reactions
+		 WHERE message_id = $1 AND user_id = $2 AND emoji = $3`,
+		messageID, userID, emoji,
+	)
+	if err != nil {
+		return false, fmt.Errorf("RemoveReaction: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("RemoveReaction: rows affected: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// FindReactionGroups returns the reactions of each message grouped by emoji,
+// in the order the emojis were first used. Deleted messages have none.
+func (r *MessageRepository) FindReactionGroups(
+	ctx context.Context,
+	viewerID entity.ID,
+	messageIDs []entity.ID,
+) (map[entity.ID][]*entity.ReactionGroup, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT r.message_id, r.emoji, COUNT(*), BOOL_OR(r.user_id = $2)
+		 FROM reactions AS r
+		 INNER JOIN messages AS m ON m.id = r.message_id
+		 WHERE r.message_id = ANY($1) AND m.deleted_at IS NULL
+		 GROUP BY r.message_id, r.emoji
+		 ORDER BY r.message_id, MIN(r.id)`,
+		pq.Array(messageIDs), viewerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindReactionGroups: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[entity.ID][]*entity.ReactionGroup)
+
+	for rows.Next() {
+		var (
+			messageID entity.ID
+			group     entity.ReactionGroup
+		)
+
+		if err := rows.Scan(
+			&messageID,
+			&group.Emoji,
+			&group.Count,
+			&group.ViewerReacted,
+		); err != nil {
+			return nil, fmt.Errorf("FindReactionGroups: %w", err)
+		}
+
+		res[messageID] = append(res[messageID], &group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindReactionGroups: %w", err)
+	}
+
+	return res, nil
+}
+
+func (s *MessageRepository) ReactionChanged(
+	ctx context.Context,
+	input entity.User,
+	conversationID *entity.ID,
+) (<-chan *entity.ReactionEvent, error) {
+	events := make(chan *entity.ReactionEvent, 1)
+
+	s.reactionSubscriptions.Subscribe(ctx, input.ID,
+		func(ctx context.Context, e interface{}) {
+			event, ok := e.(*entity.ReactionEvent)
+			if !ok {
+				return
+			}
+
+			if conversationID != nil && *conversationID != event.Message.ConversationID {
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		},
+		func() { close(events) },
+	)
+
+	return events, nil
+}
+
+// FanoutReactionEvent publishes the event to the participants of the
+// conversation of its message.
+func (s *MessageRepository) FanoutReactionEvent(
+	ctx context.Context,
+	event *entity.ReactionEvent,
+) error {
+	participantIDs, err := s.findParticipantIDs(ctx, event.Message.ConversationID)
+	if err != nil {
+		return fmt.Errorf("FanoutReactionEvent: find participant ids: %w", err)
+	}
+
+	if err := publishEvent(ctx, s.eventBus, topicReactionChanged, participantIDs,
+		event); err != nil {
+		return fmt.Errorf("FanoutReactionEvent: %w", err)
+	}
+
+	return nil
+}
+
+func decodeReactionEvent(payload json.RawMessage) (interface{}, error) {
+	var event entity.ReactionEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	if event.Message == nil {
+		return nil, fmt.Errorf("reaction event without message")
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+// MarkConversationRead moves the read receipt of the user up to the message,
+// it never moves back to an older one.
+func (r *MessageRepository) MarkConversationRead(
+	ctx context.Context,
+	conversationID entity.ID,
+	userID entity.ID,
+	messageID entity.ID,
+) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		`UPDATE participants
+		    SET last_read_message_id = $3, last_read_at = NOW(), updated_at = NOW()
+		  WHERE conversation_id = $1 AND user_id = $2
+		    AND (last_read_message_id IS NULL OR last_read_message_id < $3)`,
+		conversationID, userID, messageID,
+	); err != nil {
+		return fmt.Errorf("MarkConversationRead: %w", err)
+	}
+
+	return nil
+}
+
+// FindUnreadCounts returns how many messages of others the user has not read
+// in each conversation, deleted messages and thread replies left aside like
+// in the messages of the conversation.
+func (r *MessageRepository) FindUnreadCounts(
+	ctx context.Context,
+	userID entity.ID,
+	conversationIDs []entity.ID,
+) (map[entity.ID]int, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT p.conversation_id, COUNT(m.id)
+		 FROM participants AS p
+		 LEFT JOIN messages AS m
+		   ON m.conversation_id = p.conversation_id
+		  AND m.id > COALESCE(p.last_read_message_id, 0)
+		  AND m.sender_id <> p.user_id
+		  AND m.parent_id IS NULL
+		  AND m.deleted_at IS NULL
+		 WHERE p.user_id = $1 AND p.conversation_id = ANY($2)
+		 GROUP BY p.conversation_id`,
+		userID, pq.Array(conversationIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindUnreadCounts: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[entity.ID]int)
+
+	for rows.Next() {
+		var (
+			conversationID entity.ID
+			count          int
+		)
+
+		if err := rows.Scan(&conversationID, &count); err != nil {
+			return nil, fmt.Errorf("FindUnreadCounts: %w", err)
+		}
+
+		res[conversationID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindUnreadCounts: %w", err)
+	}
+
+	return res, nil
+}
+
+// FindReadReceipts returns the read receipts of the participants of each
+// conversation.
+func (r *MessageRepository) FindReadReceipts(
+	ctx context.Context,
+	conversationIDs []entity.ID,
+) (map[entity.ID][]*entity.ReadReceipt, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT conversation_id, user_id, last_read_message_id, last_read_at
+		 FROM participants
+		 WHERE conversation_id = ANY($1)
+		 ORDER BY conversation_id, user_id`,
+		pq.Array(conversationIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("FindReadReceipts: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[entity.ID][]*entity.ReadReceipt)
+
+	for rows.Next() {
+		var receipt entity.ReadReceipt
+		if err := rows.Scan(
+			&receipt.ConversationID,
+			&receipt.UserID,
+			&receipt.LastReadMessageID,
+			&receipt.ReadAt,
+		); err != nil {
+			return nil, fmt.Errorf("FindReadReceipts: %w", err)
+		}
+
+		res[receipt.ConversationID] = append(res[receipt.ConversationID], &receipt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("FindReadReceipts: %w", err)
+	}
+
+	return res, nil
+}
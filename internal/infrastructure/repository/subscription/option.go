@@ -0,0 +1,44 @@
+package subscription
+
+import "expvar"
+
+const defaultQueueSize = 32
+
+// metrics is published on /debug/vars, every registry reports
+// <name>.dropped, <name>.coalesced and <name>.disconnected.
+var metrics = expvar.NewMap("subscriptions")
+
+type Option struct {
+	QueueSize      int
+	OverflowPolicy OverflowPolicy
+}
+
+type OverflowPolicy string
+
+const (
+	// OverflowPolicyDropOldest drops the oldest pending event to make room.
+	OverflowPolicyDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowPolicyDisconnect ends the subscription of the slow consumer.
+	OverflowPolicyDisconnect OverflowPolicy = "disconnect"
+	// OverflowPolicyCoalesce replaces a pending event with the same key and
+	// falls back to dropping the oldest one.
+	OverflowPolicyCoalesce OverflowPolicy = "coalesce"
+)
+
+func overflowPolicies() []OverflowPolicy {
+	return []OverflowPolicy{
+		OverflowPolicyDropOldest,
+		OverflowPolicyDisconnect,
+		OverflowPolicyCoalesce,
+	}
+}
+
+func IsValidOverflowPolicy(policy string) bool {
+	for _, p := range overflowPolicies() {
+		if string(p) == policy {
+			return true
+		}
+	}
+
+	return false
+}
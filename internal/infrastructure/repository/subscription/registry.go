@@ -0,0 +1,131 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/segmentio/ksuid"
+)
+
+// Registry keeps every live subscription, a user can hold many of them at
+// the same time.
+type Registry struct {
+	name          string
+	options       Option
+	subscriptions map[entity.ID]map[string]*Subscription
+	mutex         sync.RWMutex
+}
+
+func NewRegistry(name string, options Option) *Registry {
+	if options.QueueSize <= 0 {
+		options.QueueSize = defaultQueueSize
+	}
+
+	if !IsValidOverflowPolicy(string(options.OverflowPolicy)) {
+		options.OverflowPolicy = OverflowPolicyDropOldest
+	}
+
+	return &Registry{
+		name:          name,
+		options:       options,
+		subscriptions: map[entity.ID]map[string]*Subscription{},
+		mutex:         sync.RWMutex{},
+	}
+}
+
+// Subscribe registers a new subscription for the user. Events are handed to
+// handler from a dedicated goroutine, closer is called once the subscription
+// ends, either because ctx is done or because it was disconnected.
+func (r *Registry) Subscribe(
+	ctx context.Context,
+	userID entity.ID,
+	handler Handler,
+	closer func(),
+) *Subscription {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := &Subscription{
+		ID:      ksuid.New().String(),
+		UserID:  userID,
+		handler: handler,
+		closer:  closer,
+		queue:   make([]interface{}, 0, r.options.QueueSize),
+		notify:  make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	r.mutex.Lock()
+	if _, ok := r.subscriptions[userID]; !ok {
+		r.subscriptions[userID] = map[string]*Subscription{}
+	}
+	r.subscriptions[userID][sub.ID] = sub
+	r.mutex.Unlock()
+
+	go func() {
+		sub.run()
+
+		r.remove(sub)
+	}()
+
+	return sub
+}
+
+// Unsubscribe ends the subscription without touching the other
+// subscriptions of the same user.
+func (r *Registry) Unsubscribe(sub *Subscription) {
+	sub.cancel()
+}
+
+func (r *Registry) remove(sub *Subscription) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	subs, ok := r.subscriptions[sub.UserID]
+	if !ok {
+		return
+	}
+
+	delete(subs, sub.ID)
+	if len(subs) == 0 {
+		delete(r.subscriptions, sub.UserID)
+	}
+}
+
+// Publish queues the event for every subscription of the given users, it
+// never blocks on a slow subscriber.
+func (r *Registry) Publish(userIDs []entity.ID, event interface{}) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, id := range userIDs {
+		for _, sub := range r.subscriptions[id] {
+			r.enqueue(sub, event)
+		}
+	}
+}
+
+// Broadcast queues the event for every subscription.
+func (r *Registry) Broadcast(event interface{}) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, subs := range r.subscriptions {
+		for _, sub := range subs {
+			r.enqueue(sub, event)
+		}
+	}
+}
+
+func (r *Registry) enqueue(sub *Subscription, event interface{}) {
+	switch sub.enqueue(event, r.options) {
+	case enqueueResultDropped:
+		metrics.Add(r.name+".dropped", 1)
+	case enqueueResultCoalesced:
+		metrics.Add(r.name+".coalesced", 1)
+	case enqueueResultDisconnected:
+		metrics.Add(r.name+".dropped", 1)
+		metrics.Add(r.name+".disconnected", 1)
+	}
+}
@@ -0,0 +1,208 @@
+package subscription
+
+import (
+	"context"
+	"expvar"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+const waitTimeout = time.Second
+
+func metric(name string) int64 {
+	v, ok := metrics.Get(name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+
+	return v.Value()
+}
+
+func TestRegistryOverflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		// published while the handler is busy with the first event, the
+		// queue holds 3 of them
+		published    []interface{}
+		delivered    []interface{}
+		closed       bool
+		dropped      int64
+		coalesced    int64
+		disconnected int64
+	}{
+		{
+			name:      "drop oldest keeps the latest events",
+			policy:    OverflowPolicyDropOldest,
+			published: []interface{}{1, 2, 3, 4, 5},
+			delivered: []interface{}{0, 3, 4, 5},
+			dropped:   2,
+		},
+		{
+			name:      "drop oldest does not coalesce",
+			policy:    OverflowPolicyDropOldest,
+			published: []interface{}{Keyed("a", 1), Keyed("a", 2), Keyed("a", 3)},
+			delivered: []interface{}{0, 1, 2, 3},
+		},
+		{
+			name:      "disconnect below the queue size",
+			policy:    OverflowPolicyDisconnect,
+			published: []interface{}{1, 2, 3},
+			delivered: []interface{}{0, 1, 2, 3},
+		},
+		{
+			name:         "disconnect ends the subscription",
+			policy:       OverflowPolicyDisconnect,
+			published:    []interface{}{1, 2, 3, 4, 5},
+			delivered:    []interface{}{0},
+			closed:       true,
+			dropped:      1,
+			disconnected: 1,
+		},
+		{
+			name:   "coalesce replaces the pending event of the same key",
+			policy: OverflowPolicyCoalesce,
+			published: []interface{}{Keyed("a", 1), Keyed("b", 2), Keyed("a", 3),
+				Keyed("a", 4), 5},
+			delivered: []interface{}{0, 4, 2, 5},
+			coalesced: 2,
+		},
+		{
+			name:   "coalesce drops the oldest without a pending key",
+			policy: OverflowPolicyCoalesce,
+			published: []interface{}{Keyed("a", 1), Keyed("b", 2), Keyed("c", 3),
+				Keyed("d", 4), 5},
+			delivered: []interface{}{0, 3, 4, 5},
+			dropped:   2,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "test" + entity.ID(i).String()
+			registry := NewRegistry(name, Option{QueueSize: 3, OverflowPolicy: tt.policy})
+
+			// the metrics are global, only their increase is checked
+			wantMetrics := map[string]int64{
+				name + ".dropped":      tt.dropped,
+				name + ".coalesced":    tt.coalesced,
+				name + ".disconnected": tt.disconnected,
+			}
+			for metricName := range wantMetrics {
+				wantMetrics[metricName] += metric(metricName)
+			}
+
+			var (
+				started   = make(chan struct{})
+				release   = make(chan struct{})
+				delivered = make(chan interface{}, 16)
+				closed    = make(chan struct{})
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			registry.Subscribe(ctx, 1,
+				func(ctx context.Context, event interface{}) {
+					delivered <- event
+					if event == 0 {
+						close(started)
+						<-release
+					}
+				},
+				func() { close(closed) },
+			)
+
+			registry.Publish([]entity.ID{1}, 0)
+
+			select {
+			case <-started:
+			case <-time.After(waitTimeout):
+				t.Fatal("the first event was not delivered")
+			}
+
+			// the events of other users are not queued
+			registry.Publish([]entity.ID{2}, -1)
+
+			for _, event := range tt.published {
+				registry.Publish([]entity.ID{1}, event)
+			}
+
+			close(release)
+
+			var got []interface{}
+
+			for len(got) < len(tt.delivered) {
+				select {
+				case event := <-delivered:
+					got = append(got, event)
+				case <-time.After(waitTimeout):
+					t.Fatalf("got %v, want %v", got, tt.delivered)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.delivered) {
+				t.Errorf("got %v, want %v", got, tt.delivered)
+			}
+
+			if tt.closed {
+				select {
+				case <-closed:
+				case <-time.After(waitTimeout):
+					t.Error("the subscription was not closed")
+				}
+			} else {
+				select {
+				case <-closed:
+					t.Error("the subscription was closed")
+				default:
+				}
+			}
+
+			for metricName, want := range wantMetrics {
+				if got := metric(metricName); got != want {
+					t.Errorf("%v: got %v, want %v", metricName, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryUnsubscribe(t *testing.T) {
+	registry := NewRegistry("test_unsubscribe", Option{})
+	closed := make(chan struct{})
+
+	sub := registry.Subscribe(context.Background(), 1,
+		func(ctx context.Context, event interface{}) {},
+		func() { close(closed) },
+	)
+
+	registry.Unsubscribe(sub)
+
+	select {
+	case <-closed:
+	case <-time.After(waitTimeout):
+		t.Fatal("the subscription was not closed")
+	}
+
+	// the subscription is removed once its goroutine returned
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		registry.mutex.RLock()
+		_, ok := registry.subscriptions[1]
+		registry.mutex.RUnlock()
+
+		if !ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the subscription is still registered")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,148 @@
+package subscription
+
+import (
+	"context"
+	"sync"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+// Handler receives the events published to a subscription, it may block
+// until ctx is done.
+type Handler func(ctx context.Context, event interface{})
+
+// Subscription is a single live subscription of a user, e.g. one browser tab.
+// Published events wait in a bounded queue until the handler takes them.
+type Subscription struct {
+	ID      string
+	UserID  entity.ID
+	handler Handler
+	closer  func()
+	queue   []interface{}
+	mutex   sync.Mutex
+	notify  chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+type enqueueResult int
+
+const (
+	enqueueResultQueued enqueueResult = iota
+	enqueueResultDropped
+	enqueueResultCoalesced
+	enqueueResultDisconnected
+)
+
+// keyedEvent carries the key used by OverflowPolicyCoalesce.
+type keyedEvent struct {
+	key   string
+	event interface{}
+}
+
+// Keyed marks the event so that it replaces a pending event with the same key
+// when the subscription uses OverflowPolicyCoalesce.
+func Keyed(key string, event interface{}) interface{} {
+	return keyedEvent{key: key, event: event}
+}
+
+func (s *Subscription) enqueue(event interface{}, options Option) enqueueResult {
+	if s.ctx.Err() != nil {
+		return enqueueResultQueued
+	}
+
+	s.mutex.Lock()
+	result := enqueueResultQueued
+
+	switch {
+	case options.OverflowPolicy == OverflowPolicyCoalesce && s.replace(event):
+		result = enqueueResultCoalesced
+	case len(s.queue) < options.QueueSize:
+		s.queue = append(s.queue, event)
+	case options.OverflowPolicy == OverflowPolicyDisconnect:
+		s.queue = nil
+		result = enqueueResultDisconnected
+	default:
+		s.queue = append(s.queue[1:], event)
+		result = enqueueResultDropped
+	}
+	s.mutex.Unlock()
+
+	if result == enqueueResultDisconnected {
+		s.cancel()
+
+		return result
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return result
+}
+
+// replace overwrites a pending event which has the same key, the caller must
+// hold the mutex.
+func (s *Subscription) replace(event interface{}) bool {
+	ke, ok := event.(keyedEvent)
+	if !ok {
+		return false
+	}
+
+	for i, queued := range s.queue {
+		if qke, ok := queued.(keyedEvent); ok && qke.key == ke.key {
+			s.queue[i] = event
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Subscription) dequeue() (interface{}, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+
+	event := s.queue[0]
+	s.queue = s.queue[1:]
+
+	if ke, ok := event.(keyedEvent); ok {
+		event = ke.event
+	}
+
+	return event, true
+}
+
+func (s *Subscription) run() {
+	defer func() {
+		if s.closer != nil {
+			s.closer()
+		}
+	}()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.notify:
+		}
+
+		for {
+			event, ok := s.dequeue()
+			if !ok {
+				break
+			}
+
+			s.handler(s.ctx, event)
+
+			if s.ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/external"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	// typingTimeout is how long a user counts as typing after the last
+	// start, clients keep sending starts while the user types.
+	typingTimeout = 6 * time.Second
+	// participantIDsKeyPrefix holds per conversation the ids of its
+	// participants, so that typing, sent on every keystroke, does not reach
+	// the database. They are only trusted while the generation they were read
+	// at is the current one, which every change of the participants renews.
+	participantIDsKeyPrefix           = "participant_ids:"
+	participantIDsGenerationKeyPrefix = "participant_ids_generation:"
+	participantIDsTTL                 = time.Minute
+	// participantIDsGenerationTTL outlives the cached participants, so that
+	// none of them can see the generation they were read at come back.
+	participantIDsGenerationTTL = 2 * participantIDsTTL
+)
+
+// cachedParticipantIDs are the participants of a conversation as read at a
+// generation.
+type cachedParticipantIDs struct {
+	Generation string      `json:"generation"`
+	IDs        []entity.ID `json:"ids"`
+}
+
+// typingTracker hands the typing events of the bus to the local subscriptions
+// and stops the indicators which were not refreshed in time. Every instance
+// expires the indicators it received on its own, so nothing is kept outside
+// of memory.
+type typingTracker struct {
+	registry *subscription.Registry
+	timers   map[string]*time.Timer
+	mutex    sync.Mutex
+}
+
+func newTypingTracker(eventBus external.EventBus,
+	registry *subscription.Registry) *typingTracker {
+	t := &typingTracker{
+		registry: registry,
+		timers:   map[string]*time.Timer{},
+	}
+
+	eventBus.Subscribe(topicTypingChanged, func(raw []byte) {
+		var e event
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return
+		}
+
+		var typing entity.TypingEvent
+		if err := json.Unmarshal(e.Payload, &typing); err != nil {
+			return
+		}
+
+		t.receive(e.RecipientIDs, &typing)
+	})
+
+	return t
+}
+
+func (t *typingTracker) receive(recipientIDs []entity.ID, typing *entity.TypingEvent) {
+	key := fmt.Sprintf("%v:%v", typing.ConversationID, typing.UserID)
+
+	t.mutex.Lock()
+	if timer, ok := t.timers[key]; ok {
+		timer.Stop()
+		delete(t.timers, key)
+	}
+
+	if typing.Typing {
+		var timer *time.Timer
+		timer = time.AfterFunc(typingTimeout, func() {
+			t.mutex.Lock()
+			expired := t.timers[key] == timer
+			if expired {
+				delete(t.timers, key)
+			}
+			t.mutex.Unlock()
+
+			if expired {
+				t.registry.Publish(recipientIDs, subscription.Keyed(key, &entity.TypingEvent{
+					ConversationID: typing.ConversationID,
+					UserID:         typing.UserID,
+					Typing:         false,
+				}))
+			}
+		})
+		t.timers[key] = timer
+	}
+	t.mutex.Unlock()
+
+	t.registry.Publish(recipientIDs, subscription.Keyed(key, typing))
+}
+
+// FindCachedParticipantIDs returns the participants of the conversation,
+// none once it is deleted. They are read from the cache, unless the
+// participants changed since they were cached.
+func (s *MessageRepository) FindCachedParticipantIDs(
+	ctx context.Context,
+	conversationID entity.ID,
+) ([]entity.ID, error) {
+	fail := func(err error) ([]entity.ID, error) {
+		return nil, fmt.Errorf("FindCachedParticipantIDs: %w", err)
+	}
+
+	key := participantIDsKeyPrefix + conversationID.String()
+
+	values, err := s.cacher.MGet(participantIDsGenerationKeyPrefix+conversationID.String(), key)
+	if err != nil {
+		return fail(err)
+	}
+
+	// the generation is read before the database, a change committed in
+	// between renews it and the participants cached below are not trusted
+	var generation string
+	if values[0] != nil {
+		generation = *values[0]
+	}
+
+	if values[1] != nil {
+		var cached cachedParticipantIDs
+		if err := json.Unmarshal([]byte(*values[1]), &cached); err == nil &&
+			cached.Generation == generation {
+			return cached.IDs, nil
+		}
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT p.user_id
+		 FROM participants AS p
+		 INNER JOIN conversations AS c ON c.id = p.conversation_id
+		 WHERE p.conversation_id = $1 AND c.deleted_at IS NULL`,
+		conversationID,
+	)
+	if err != nil {
+		return fail(err)
+	}
+	defer rows.Close()
+
+	ids := []entity.ID{}
+
+	for rows.Next() {
+		var id entity.ID
+		if err := rows.Scan(&id); err != nil {
+			return fail(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fail(err)
+	}
+
+	payload, _ := json.Marshal(&cachedParticipantIDs{Generation: generation, IDs: ids})
+	// skip error, the next keystroke reads the database again
+	_ = s.cacher.SetWithTTL(key, payload, participantIDsTTL)
+
+	return ids, nil
+}
+
+// RenewParticipantIDsGeneration makes the cached participants of the
+// conversation stale, it must be called once a change of them is committed.
+func (s *MessageRepository) RenewParticipantIDsGeneration(
+	ctx context.Context,
+	conversationID entity.ID,
+) error {
+	if err := s.cacher.SetWithTTL(
+		participantIDsGenerationKeyPrefix+conversationID.String(),
+		[]byte(ksuid.New().String()),
+		participantIDsGenerationTTL,
+	); err != nil {
+		return fmt.Errorf("RenewParticipantIDsGeneration: %w", err)
+	}
+
+	return nil
+}
+
+// PublishTyping sends the typing event to the given participants of its
+// conversation, it is never stored.
+func (s *MessageRepository) PublishTyping(
+	ctx context.Context,
+	typing *entity.TypingEvent,
+	participantIDs []entity.ID,
+) error {
+	if err := publishEvent(ctx, s.eventBus, topicTypingChanged, participantIDs,
+		typing); err != nil {
+		return fmt.Errorf("PublishTyping: %w", err)
+	}
+
+	return nil
+}
+
+func (s *MessageRepository) TypingChanged(
+	ctx context.Context,
+	input entity.User,
+	conversationID entity.ID,
+) (<-chan *entity.TypingEvent, error) {
+	events := make(chan *entity.TypingEvent, 1)
+
+	s.typingSubscriptions.Subscribe(ctx, input.ID,
+		func(ctx context.Context, e interface{}) {
+			typing, ok := e.(*entity.TypingEvent)
+			if !ok || typing.ConversationID != conversationID {
+				return
+			}
+
+			select {
+			case events <- typing:
+			case <-ctx.Done():
+			}
+		},
+		func() { close(events) },
+	)
+
+	return events, nil
+}
@@ -6,53 +6,75 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"sync"
+	"log"
 
 	"github.com/lib/pq"
 	"github.com/samthehai/chat/internal/domain/entity"
 	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+	"github.com/samthehai/chat/internal/infrastructure/repository/cursor"
 	"github.com/samthehai/chat/internal/infrastructure/repository/external"
 	"github.com/samthehai/chat/internal/infrastructure/repository/model"
+	"github.com/samthehai/chat/internal/infrastructure/repository/subscription"
 )
 
 const usersKey = "users"
 
 type UserRepository struct {
-	cacher        external.Cacher
-	authenticator external.Authenticator
-	userChans     map[entity.ID]chan *entity.User
-	mutex         sync.Mutex
-	db            *sql.DB
+	cacher            external.Cacher
+	eventBus          external.EventBus
+	authenticator     external.Authenticator
+	userSubscriptions *subscription.Registry
+	cursorCodec       *cursor.Codec
+	db                *sql.DB
 }
 
 func NewUserRepository(
 	cacher external.Cacher,
+	eventBus external.EventBus,
 	authenticator external.Authenticator,
 	db *sql.DB,
+	subscriptionOption subscription.Option,
+	cursorCodec *cursor.Codec,
 ) *UserRepository {
-	return &UserRepository{
-		cacher:        cacher,
-		authenticator: authenticator,
-		db:            db,
-		userChans:     map[entity.ID]chan *entity.User{},
-		mutex:         sync.Mutex{},
+	r := &UserRepository{
+		cacher:            cacher,
+		eventBus:          eventBus,
+		authenticator:     authenticator,
+		db:                db,
+		cursorCodec:       cursorCodec,
+		userSubscriptions: subscription.NewRegistry(topicUserJoined, subscriptionOption),
 	}
+
+	listenEvents(eventBus, topicUserJoined, r.userSubscriptions,
+		func(payload json.RawMessage) (interface{}, error) {
+			var user entity.User
+			if err := json.Unmarshal(payload, &user); err != nil {
+				return nil, err
+			}
+
+			return &user, nil
+		})
+
+	return r
 }
 
 func (r *UserRepository) UserJoined(ctx context.Context, input entity.User) (<-chan *entity.User, error) {
 	users := make(chan *entity.User, 1)
 
-	r.mutex.Lock()
-	r.userChans[input.ID] = users
-	r.mutex.Unlock()
-
-	go func() {
-		<-ctx.Done()
-
-		r.mutex.Lock()
-		delete(r.userChans, input.ID)
-		r.mutex.Unlock()
-	}()
+	r.userSubscriptions.Subscribe(ctx, input.ID,
+		func(ctx context.Context, event interface{}) {
+			user, ok := event.(*entity.User)
+			if !ok {
+				return
+			}
+
+			select {
+			case users <- user:
+			case <-ctx.Done():
+			}
+		},
+		func() { close(users) },
+	)
 
 	return users, nil
 }
@@ -151,11 +173,10 @@ func (r *UserRepository) AddUser(ctx context.Context, input entity.User) (*entit
 		return nil, fmt.Errorf("find by firebase id: %w", err)
 	}
 
-	r.mutex.Lock()
-	for _, ch := range r.userChans {
-		ch <- createdUser
+	// the user is stored already, a lost event must not fail the sign up
+	if err := publishEvent(ctx, r.eventBus, topicUserJoined, nil, createdUser); err != nil {
+		log.Printf("failed to publish user joined: %v\n", err)
 	}
-	r.mutex.Unlock()
 
 	return createdUser, nil
 }
@@ -178,271 +199,98 @@ func (r *UserRepository) GetAuthTokenFromContext(ctx context.Context) (*entity.A
 	return token, nil
 }
 
-func (r *UserRepository) FindFriends(ctx context.Context, first int, after entity.ID, sortBy entity.FriendsSortByType, sortOrder entity.SortOrderType) (*entity.FriendsConnection, error) {
-	if !entity.IsValidFriendsSortByType(string(sortBy)) {
-		return nil, fmt.Errorf("invalid sortBy: %v", sortBy)
+func (r *UserRepository) FindFriends(ctx context.Context, userID entity.ID,
+	input entity.ListQueryInput) (*entity.FriendsConnection, error) {
+	friendsPage, err := r.friendsPage(entity.RelayQueryInput{
+		KeyID:          userID,
+		ListQueryInput: input,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	sortColumn := model.GetColumnNameByFriendsSortByType(sortBy)
-	var (
-		query string
-		rows  *sql.Rows
-		err   error
-	)
+	connections, err := findIDsConnections(ctx, r.db, r.cursorCodec, []keyedPage{friendsPage})
+	if err != nil {
+		return nil, fmt.Errorf("find friend ids: %w", err)
+	}
+
+	idsConnection := connections[0]
 
-	if after == 0 {
-		query =
-			"SELECT id, name, picture_url, firebase_id, provider, email_address, email_verified, " +
-				"FALSE AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM users " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1 + 1 " +
-				"  ) as np " +
-				" ) = $1 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM users " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1"
-
-		rows, err = r.db.QueryContext(ctx, query, first)
-	} else {
-		query =
-			"SELECT id, name, picture_url, firebase_id, provider, email_address, email_verified, " +
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) FROM users " +
-				"   WHERE " + sortColumn + " <= (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"   AND id != $2 " +
-				"   AND id NOT IN " +
-				"    (SELECT id FROM users " +
-				"      WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"      AND id >= $2 ) " +
-				" ) > 0 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM users " +
-				"   WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"   AND id != $2 " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1 + 1 " +
-				"  ) as np " +
-				" ) = $1 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM users " +
-				"WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"AND id != $2 " +
-				"AND id NOT IN ( " +
-				" SELECT id FROM users " +
-				" WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				" AND id <= $2 " +
-				") " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1"
-
-		rows, err = r.db.QueryContext(ctx, query, first, after)
+	ids := make([]entity.ID, 0, len(idsConnection.Edges))
+	for _, edge := range idsConnection.Edges {
+		ids = append(ids, edge.Node)
 	}
 
+	users, err := r.FindUsers(ctx, ids)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("find users: %w", err)
 	}
-	defer rows.Close()
-
-	var (
-		userFriendEdges []*entity.FriendsEdge
-		hasNextPage     bool
-		hasPreviousPage bool
-	)
 
-	for rows.Next() {
-		var edge struct {
-			model.User
-			HasNextPage     bool `json:"has_next_page"`
-			HasPreviousPage bool `json:"has_previous_page"`
-		}
-
-		if err := rows.Scan(
-			&edge.ID,
-			&edge.Name,
-			&edge.PictureUrl,
-			&edge.FirebaseID,
-			&edge.Provider,
-			&edge.EmailAddress,
-			&edge.EmailVerified,
-			&edge.HasNextPage,
-			&edge.HasPreviousPage,
-		); err != nil {
-			return nil, err
-		}
+	usersByID := make(map[entity.ID]*entity.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
 
-		hasNextPage = edge.HasNextPage
-		hasPreviousPage = edge.HasPreviousPage
-		user := model.ConvertModelUser(&edge.User)
+	userFriendEdges := make([]*entity.FriendsEdge, 0, len(idsConnection.Edges))
+	for _, edge := range idsConnection.Edges {
 		userFriendEdges = append(userFriendEdges, &entity.FriendsEdge{
-			Node:   user,
-			Cursor: user.ID,
+			Node:   usersByID[edge.Node],
+			Cursor: edge.Cursor,
 		})
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
 	return &entity.FriendsConnection{
-		Edges: userFriendEdges,
-		PageInfo: &entity.PageInfo{
-			HasPreviousPage: hasPreviousPage,
-			HasNextPage:     hasNextPage,
-		},
-		TotalCount: len(userFriendEdges),
+		Edges:      userFriendEdges,
+		PageInfo:   idsConnection.PageInfo,
+		TotalCount: idsConnection.TotalCount,
 	}, nil
 }
 
 func (r *UserRepository) GetFriendIDsFromUserIDs(ctx context.Context,
 	inputs []entity.RelayQueryInput) (map[entity.ID]*entity.IDsConnection, error) {
-	// TODO: find a better solution
-	res := make(map[entity.ID]*entity.IDsConnection)
+	pages := make([]keyedPage, 0, len(inputs))
 	for _, input := range inputs {
-		idsConnection, err := r.getFriendIDsFromUserID(ctx, input)
+		friendsPage, err := r.friendsPage(input)
 		if err != nil {
-			return nil, fmt.Errorf("get friend ids from user: %w", err)
+			return nil, err
 		}
 
-		res[input.KeyID] = idsConnection
-	}
-
-	return res, nil
-}
-
-func (r *UserRepository) getFriendIDsFromUserID(ctx context.Context,
-	input entity.RelayQueryInput) (*entity.IDsConnection, error) {
-	if !entity.IsValidFriendsSortByType(string(input.SortBy)) {
-		return nil, fmt.Errorf("invalid sortBy: %v", input.SortBy)
-	}
-
-	sortColumn := model.GetColumnNameByFriendsSortByType(
-		entity.FriendsSortByType(input.SortBy))
-	var (
-		query string
-		rows  *sql.Rows
-		err   error
-	)
-
-	if input.After == 0 {
-		query =
-			"SELECT id, " +
-				"FALSE AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM users " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1 + 1 " +
-				"  ) as np " +
-				" ) = $1 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM users " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1"
-
-		rows, err = r.db.QueryContext(ctx, query, input.First)
-	} else {
-		query =
-			"SELECT id, " +
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) FROM users " +
-				"   WHERE " + sortColumn + " <= (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"   AND id != $2 " +
-				"   AND id NOT IN " +
-				"    (SELECT id FROM users " +
-				"      WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"      AND id >= $2 ) " +
-				" ) > 0 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_previous_page, " +
-
-				"CASE " +
-				" WHEN ( " +
-				"  SELECT COUNT(*) " +
-				"  FROM ( " +
-				"   SELECT * FROM users " +
-				"   WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"   AND id != $2 " +
-				"   ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1 + 1 " +
-				"  ) as np " +
-				" ) = $1 + 1 " +
-				"THEN TRUE ELSE FALSE " +
-				"END AS has_next_page " +
-
-				"FROM users " +
-				"WHERE " + sortColumn + " >= (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				"AND id != $2 " +
-				"AND id NOT IN ( " +
-				" SELECT id FROM users " +
-				" WHERE " + sortColumn + " = (SELECT " + sortColumn + " FROM users WHERE id = $2 ) " +
-				" AND id <= $2 " +
-				") " +
-				"ORDER BY " + sortColumn + " ASC, id ASC LIMIT $1"
-
-		rows, err = r.db.QueryContext(ctx, query, input.First, input.After)
+		pages = append(pages, friendsPage)
 	}
 
+	connections, err := findIDsConnections(ctx, r.db, r.cursorCodec, pages)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("find friend ids: %w", err)
 	}
-	defer rows.Close()
 
-	var (
-		idEdges         []*entity.IDsEdge
-		hasNextPage     bool
-		hasPreviousPage bool
-	)
+	res := make(map[entity.ID]*entity.IDsConnection)
+	for i, input := range inputs {
+		res[input.KeyID] = connections[i]
+	}
 
-	for rows.Next() {
-		var edge struct {
-			ID              entity.ID `json:"id"`
-			HasNextPage     bool      `json:"has_next_page"`
-			HasPreviousPage bool      `json:"has_previous_page"`
-		}
+	return res, nil
+}
 
-		if err := rows.Scan(
-			&edge.ID,
-			&edge.HasNextPage,
-			&edge.HasPreviousPage,
-		); err != nil {
-			return nil, err
-		}
+func (r *UserRepository) friendsPage(input entity.RelayQueryInput) (keyedPage, error) {
+	if !entity.IsValidFriendsSortByType(input.SortBy) {
+		return keyedPage{}, fmt.Errorf("invalid sortBy: %v", input.SortBy)
+	}
 
-		hasNextPage = edge.HasNextPage
-		hasPreviousPage = edge.HasPreviousPage
-		idEdges = append(idEdges, &entity.IDsEdge{
-			Node:   edge.ID,
-			Cursor: edge.ID,
-		})
+	k := keyset{
+		from: "friendships AS f INNER JOIN users AS u ON u.id = f.friend_id",
+		where: fmt.Sprintf("f.user_id = k.key_id AND f.status = '%s'",
+			entity.FriendshipStatusAccepted),
+		idColumn: "u.id",
+		sortColumn: "u." + model.GetColumnNameByFriendsSortByType(
+			entity.FriendsSortByType(input.SortBy)),
+		sortType:  "TEXT",
+		sortOrder: input.SortOrder,
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	p, err := newPage(input.ListQueryInput, k, r.cursorCodec)
+	if err != nil {
+		return keyedPage{}, err
 	}
 
-	return &entity.IDsConnection{
-		Edges: idEdges,
-		PageInfo: &entity.PageInfo{
-			HasPreviousPage: hasPreviousPage,
-			HasNextPage:     hasNextPage,
-		},
-		TotalCount: len(idEdges),
-	}, nil
+	return keyedPage{keyset: k, key: input.KeyID, page: p}, nil
 }
@@ -1 +1,327 @@
 package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/infrastructure/repository/cursor"
+)
+
+// keyset describes a list paginated by seeking on (sortColumn, idColumn), the
+// id breaks ties between rows sharing the same sort value. where selects the
+// rows belonging to the key of a page, referred to as k.key_id. sortType is
+// the SQL type the sort value of a cursor is cast back to.
+type keyset struct {
+	from       string
+	where      string
+	idColumn   string
+	sortColumn string
+	sortType   string
+	sortOrder  entity.SortOrderType
+}
+
+// page is a normalized relay pagination request, backward pages are read in
+// reverse order and flipped back by the caller.
+type page struct {
+	limit    int
+	cursor   *cursor.Position
+	backward bool
+}
+
+// sort is the order a cursor of the keyset is issued for.
+func (k keyset) sort() cursor.Sort {
+	return cursor.Sort{Key: k.sortColumn, Type: k.sortType, Order: k.sortOrder}
+}
+
+// newPage reads the pagination of input over the keyset, a cursor issued for
+// a list sorted another way is invalid.
+func newPage(input entity.ListQueryInput, k keyset, codec *cursor.Codec) (page, error) {
+	p := page{limit: input.First}
+	c := input.After
+
+	if input.IsBackward() {
+		p = page{limit: input.Last, backward: true}
+		c = input.Before
+	}
+
+	if c != "" {
+		position, err := codec.Decode(c, k.sort())
+		if err != nil {
+			return page{}, fmt.Errorf("decode cursor: %w", err)
+		}
+
+		p.cursor = position
+	}
+
+	return p, nil
+}
+
+// keyedPage is a page of the rows of one key of a batch.
+type keyedPage struct {
+	keyset keyset
+	key    entity.ID
+	page   page
+}
+
+// batchQuery builds one statement reading every page of a batch. Pages read
+// in the same order share a branch which unnests their keys and parameters
+// and picks the rows of each key in a LATERAL subquery, branches are glued
+// with UNION ALL. One more row than requested is fetched so the caller can
+// tell whether there are more rows ahead.
+//
+// Every row starts with the columns scanned by batchRow.dest, followed by
+// the requested ones. A key without rows in its page still gets one row,
+// with the columns of the page set to NULL.
+func batchQuery(columns string, pages []keyedPage) (string, []interface{}, error) {
+	type branch struct {
+		keyset   keyset
+		backward bool
+	}
+
+	var (
+		branches []branch
+		indexes  = make(map[branch][]int)
+	)
+
+	for i, p := range pages {
+		if !entity.IsValidSortOrderType(string(p.keyset.sortOrder)) {
+			return "", nil, fmt.Errorf("invalid sortOrder: %v", p.keyset.sortOrder)
+		}
+
+		b := branch{keyset: p.keyset, backward: p.page.backward}
+		if _, ok := indexes[b]; !ok {
+			branches = append(branches, b)
+		}
+
+		indexes[b] = append(indexes[b], i)
+	}
+
+	var (
+		args    []interface{}
+		queries = make([]string, 0, len(branches))
+	)
+
+	for _, b := range branches {
+		var (
+			keys         = make([]entity.ID, 0, len(indexes[b]))
+			ords         = make([]int64, 0, len(indexes[b]))
+			limits       = make([]int64, 0, len(indexes[b]))
+			cursorValues = make([]*string, 0, len(indexes[b]))
+			cursorIDs    = make([]*entity.ID, 0, len(indexes[b]))
+		)
+
+		for _, i := range indexes[b] {
+			p := pages[i]
+
+			keys = append(keys, p.key)
+			ords = append(ords, int64(i))
+			limits = append(limits, int64(p.page.limit+1))
+
+			if p.page.cursor != nil {
+				cursorValues = append(cursorValues, &p.page.cursor.Value)
+				cursorIDs = append(cursorIDs, &p.page.cursor.ID)
+			} else {
+				cursorValues = append(cursorValues, nil)
+				cursorIDs = append(cursorIDs, nil)
+			}
+		}
+
+		args = append(args, pq.Array(keys), pq.Array(ords), pq.Array(limits),
+			pq.Array(cursorValues), pq.Array(cursorIDs))
+		queries = append(queries, b.keyset.branch(columns, b.backward, len(args)-4))
+	}
+
+	query := fmt.Sprintf("SELECT * FROM (%s) AS batch ORDER BY ord, row_index",
+		strings.Join(queries, " UNION ALL "))
+
+	return query, args, nil
+}
+
+// branch builds the part of a batch statement reading pages in one order,
+// its arrays of parameters are bound from $param onwards.
+func (k keyset) branch(columns string, backward bool, param int) string {
+	descending := k.sortOrder == entity.SortOrderTypeDES
+	if backward {
+		descending = !descending
+	}
+
+	direction, seek, behind := "ASC", ">", "<="
+	if descending {
+		direction, seek, behind = "DESC", "<", ">="
+	}
+
+	where := k.where
+	if where == "" {
+		where = "TRUE"
+	}
+
+	row := fmt.Sprintf("(%s, %s)", k.sortColumn, k.idColumn)
+	position := fmt.Sprintf("(CAST(k.cursor_value AS %s), k.cursor_id)", k.sortType)
+
+	if columns != "" {
+		columns = ", " + columns
+	}
+
+	return fmt.Sprintf(
+		"SELECT k.ord, total.total_count, behind.has_more_behind, page_rows.* "+
+			"FROM unnest(CAST($%d AS INTEGER[]), CAST($%d AS INTEGER[]), CAST($%d AS INTEGER[]), "+
+			"CAST($%d AS TEXT[]), CAST($%d AS INTEGER[])) AS k(key_id, ord, row_limit, cursor_value, cursor_id) "+
+			"CROSS JOIN LATERAL (SELECT COUNT(*) AS total_count FROM %s WHERE %s) AS total "+
+			"CROSS JOIN LATERAL (SELECT k.cursor_id IS NOT NULL AND EXISTS "+
+			"(SELECT 1 FROM %s WHERE %s AND %s %s %s) AS has_more_behind) AS behind "+
+			"LEFT JOIN LATERAL (SELECT ROW_NUMBER() OVER () AS row_index, ordered.* FROM "+
+			"(SELECT CAST(%s AS TEXT) AS sort_value, %s AS row_id%s "+
+			"FROM %s "+
+			"WHERE %s AND (k.cursor_id IS NULL OR %s %s %s) "+
+			"ORDER BY %s %s, %s %s "+
+			"LIMIT k.row_limit) AS ordered) AS page_rows ON TRUE",
+		param, param+1, param+2, param+3, param+4,
+		k.from, where,
+		k.from, where, row, behind, position,
+		k.sortColumn, k.idColumn, columns,
+		k.from,
+		where, row, seek, position,
+		k.sortColumn, direction, k.idColumn, direction,
+	)
+}
+
+// batchRow holds the columns every row of batchQuery starts with.
+type batchRow struct {
+	ord           int
+	totalCount    int
+	hasMoreBehind bool
+	rowIndex      *int
+	sortValue     *string
+	id            *entity.ID
+}
+
+func (r *batchRow) dest(columns ...interface{}) []interface{} {
+	return append([]interface{}{
+		&r.ord,
+		&r.totalCount,
+		&r.hasMoreBehind,
+		&r.rowIndex,
+		&r.sortValue,
+		&r.id,
+	}, columns...)
+}
+
+// empty reports whether the row only carries the counts of a key whose page
+// has no rows.
+func (r *batchRow) empty() bool {
+	return r.id == nil
+}
+
+func (r *batchRow) cursor(codec *cursor.Codec, k keyset) entity.Cursor {
+	return codec.Encode(cursor.Position{Sort: k.sort(), Value: *r.sortValue, ID: *r.id})
+}
+
+// trim drops the extra row fetched by batchQuery and puts the edges of a
+// backward page back in sort order. edges must be a slice, the returned
+// length is the one to cut it to.
+func (p page) trim(edges interface{}) (length int, hasMore bool) {
+	length = reflect.ValueOf(edges).Len()
+	if length > p.limit {
+		length, hasMore = p.limit, true
+	}
+
+	if p.backward {
+		swap := reflect.Swapper(edges)
+		for i, j := 0, length-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	return length, hasMore
+}
+
+// pageInfo maps what was seen in the reading direction of the page back to
+// the relay page info.
+func (p page) pageInfo(
+	hasMore bool,
+	hasMoreBehind bool,
+	startCursor *entity.Cursor,
+	endCursor *entity.Cursor,
+) *entity.PageInfo {
+	info := &entity.PageInfo{
+		HasPreviousPage: hasMoreBehind,
+		HasNextPage:     hasMore,
+		StartCursor:     startCursor,
+		EndCursor:       endCursor,
+	}
+
+	if p.backward {
+		info.HasPreviousPage, info.HasNextPage = hasMore, hasMoreBehind
+	}
+
+	return info
+}
+
+// findIDsConnections reads the pages of ids of a batch, the connections are
+// returned in the order of pages.
+func findIDsConnections(ctx context.Context, db *sql.DB, codec *cursor.Codec,
+	pages []keyedPage) ([]*entity.IDsConnection, error) {
+	query, args, err := batchQuery("", pages)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connections := make([]*entity.IDsConnection, len(pages))
+	for i := range connections {
+		connections[i] = &entity.IDsConnection{}
+	}
+
+	hasMoreBehind := make([]bool, len(pages))
+
+	for rows.Next() {
+		var row batchRow
+
+		if err := rows.Scan(row.dest()...); err != nil {
+			return nil, err
+		}
+
+		connection := connections[row.ord]
+		connection.TotalCount = row.totalCount
+		hasMoreBehind[row.ord] = row.hasMoreBehind
+
+		if row.empty() {
+			continue
+		}
+
+		connection.Edges = append(connection.Edges, &entity.IDsEdge{
+			Node:   *row.id,
+			Cursor: row.cursor(codec, pages[row.ord].keyset),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, connection := range connections {
+		p := pages[i].page
+
+		length, hasMore := p.trim(connection.Edges)
+		connection.Edges = connection.Edges[:length]
+
+		var startCursor, endCursor *entity.Cursor
+		if length > 0 {
+			startCursor, endCursor = &connection.Edges[0].Cursor, &connection.Edges[length-1].Cursor
+		}
+
+		connection.PageInfo = p.pageInfo(hasMore, hasMoreBehind[i], startCursor, endCursor)
+	}
+
+	return connections, nil
+}
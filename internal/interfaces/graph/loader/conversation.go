@@ -13,6 +13,8 @@ type ConversationLoader struct {
 	conversationLoader          *dataloader.Loader
 	conversationIDsLoader       *dataloader.Loader
 	participantsInConversations *dataloader.Loader
+	unreadCounts                *dataloader.Loader
+	readReceipts                *dataloader.Loader
 }
 
 func NewConversationLoader(
@@ -25,6 +27,10 @@ func NewConversationLoader(
 			messageUsecase.GetConversationIDsFromUserIDs),
 		participantsInConversations: newParticipantsInConversationsLoader(
 			messageUsecase.GetParticipantsInConversations),
+		unreadCounts: newUnreadCountsLoader(
+			messageUsecase.UnreadCountsOfConversations),
+		readReceipts: newReadReceiptsLoader(
+			messageUsecase.ReadReceiptsInConversations),
 	}
 }
 
@@ -51,19 +57,46 @@ func (l *ConversationLoader) LoadConversationIDsFromUser(ctx context.Context,
 }
 
 func (l *ConversationLoader) LoadParticipantsInConversation(ctx context.Context,
-	conversationID entity.ID) ([]*entity.User, error) {
+	conversationID entity.ID) ([]*entity.Participant, error) {
 	raw, err := l.participantsInConversations.Load(ctx, conversationID)()
 	if err != nil {
 		return nil, fmt.Errorf("load participants in conversation: id=%v, %w",
 			conversationID, err)
 	}
 
-	v, ok := raw.([]*entity.User)
-	if !ok {
-		return nil, fmt.Errorf("invalid user data")
+	participants, _ := raw.([]*entity.Participant)
+	if participants == nil {
+		participants = []*entity.Participant{}
 	}
 
-	return v, nil
+	return participants, nil
+}
+
+func (l *ConversationLoader) LoadUnreadCount(ctx context.Context,
+	conversationID entity.ID) (int, error) {
+	raw, err := l.unreadCounts.Load(ctx, conversationID)()
+	if err != nil {
+		return 0, fmt.Errorf("load unread count: id=%v, %w", conversationID, err)
+	}
+
+	count, _ := raw.(int)
+
+	return count, nil
+}
+
+func (l *ConversationLoader) LoadReadReceipts(ctx context.Context,
+	conversationID entity.ID) ([]*entity.ReadReceipt, error) {
+	raw, err := l.readReceipts.Load(ctx, conversationID)()
+	if err != nil {
+		return nil, fmt.Errorf("load read receipts: id=%v, %w", conversationID, err)
+	}
+
+	receipts, _ := raw.([]*entity.ReadReceipt)
+	if receipts == nil {
+		receipts = []*entity.ReadReceipt{}
+	}
+
+	return receipts, nil
 }
 
 func newConversationLoader(
@@ -75,6 +108,7 @@ func newConversationLoader(
 			ids := getIDsFromKeys(keys)
 
 			conversations, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
 			if err != nil {
 				return fillUpResultsWithError(len(keys), err)
 			}
@@ -90,7 +124,7 @@ func newConversationLoader(
 
 				results = append(results, &dataloader.Result{
 					Data:  d,
-					Error: err,
+					Error: keyErrors[id],
 				})
 			}
 
@@ -112,6 +146,7 @@ func newConversationIDsLoader(
 			}
 
 			idsConnection, err := fetchFunc(ctx, inputs)
+			keyErrors, err := splitKeyErrors(err)
 			if err != nil {
 				return fillUpResultsWithError(len(keys), err)
 			}
@@ -122,7 +157,7 @@ func newConversationIDsLoader(
 
 				results = append(results, &dataloader.Result{
 					Data:  d,
-					Error: err,
+					Error: keyErrors[input.KeyID],
 				})
 			}
 
@@ -133,13 +168,14 @@ func newConversationIDsLoader(
 
 func newParticipantsInConversationsLoader(
 	fetchFunc func(ctx context.Context,
-		conversationIDs []entity.ID) (map[entity.ID][]*entity.User, error),
+		conversationIDs []entity.ID) (map[entity.ID][]*entity.Participant, error),
 ) *dataloader.Loader {
 	return dataloader.NewBatchedLoader(
 		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
 			ids := getIDsFromKeys(keys)
 
 			participants, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
 			if err != nil {
 				return fillUpResultsWithError(len(keys), err)
 			}
@@ -150,7 +186,61 @@ func newParticipantsInConversationsLoader(
 
 				results = append(results, &dataloader.Result{
 					Data:  d,
-					Error: err,
+					Error: keyErrors[id],
+				})
+			}
+
+			return results
+		},
+	)
+}
+
+func newUnreadCountsLoader(
+	fetchFunc func(ctx context.Context,
+		conversationIDs []entity.ID) (map[entity.ID]int, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			counts, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  counts[id],
+					Error: keyErrors[id],
+				})
+			}
+
+			return results
+		},
+	)
+}
+
+func newReadReceiptsLoader(
+	fetchFunc func(ctx context.Context,
+		conversationIDs []entity.ID) (map[entity.ID][]*entity.ReadReceipt, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			receipts, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  receipts[id],
+					Error: keyErrors[id],
 				})
 			}
 
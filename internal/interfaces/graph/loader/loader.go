@@ -1,6 +1,8 @@
 package loader
 
 import (
+	"errors"
+
 	"github.com/graph-gophers/dataloader"
 	"github.com/samthehai/chat/internal/domain/entity"
 )
@@ -26,3 +28,14 @@ func fillUpResultsWithError(size int, err error) []*dataloader.Result {
 
 	return results
 }
+
+// splitKeyErrors tells the errors of single keys of a batch, which fail
+// alone, from an error of the whole batch.
+func splitKeyErrors(err error) (entity.KeyErrors, error) {
+	var keyErrors entity.KeyErrors
+	if errors.As(err, &keyErrors) {
+		return keyErrors, nil
+	}
+
+	return nil, err
+}
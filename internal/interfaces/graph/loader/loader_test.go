@@ -0,0 +1,58 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+func TestBatchedLoaderFailsForbiddenKeysAlone(t *testing.T) {
+	loader := newRevisionsLoader(func(ctx context.Context,
+		messageIDs []entity.ID) (map[entity.ID][]*entity.MessageRevision, error) {
+		// the key errors are found even when wrapped
+		return map[entity.ID][]*entity.MessageRevision{
+			1: {{ID: 10}},
+		}, fmt.Errorf("revisions: %w", entity.KeyErrors{
+			2: domainerrors.ErrForbidden,
+		})
+	})
+
+	ctx := context.Background()
+	allowed := loader.Load(ctx, entity.ID(1))
+	forbidden := loader.Load(ctx, entity.ID(2))
+
+	raw, err := allowed()
+	if err != nil {
+		t.Fatalf("allowed key: got %v", err)
+	}
+
+	if revisions := raw.([]*entity.MessageRevision); len(revisions) != 1 || revisions[0].ID != 10 {
+		t.Errorf("allowed key: got %v", revisions)
+	}
+
+	if _, err := forbidden(); !errors.Is(err, domainerrors.ErrForbidden) {
+		t.Errorf("forbidden key: got %v, want %v", err, domainerrors.ErrForbidden)
+	}
+}
+
+func TestBatchedLoaderFailsEveryKeyOnBatchError(t *testing.T) {
+	batchErr := errors.New("connection refused")
+	loader := newRevisionsLoader(func(ctx context.Context,
+		messageIDs []entity.ID) (map[entity.ID][]*entity.MessageRevision, error) {
+		return nil, batchErr
+	})
+
+	ctx := context.Background()
+	first := loader.Load(ctx, entity.ID(1))
+	second := loader.Load(ctx, entity.ID(2))
+
+	for _, thunk := range []func() (interface{}, error){first, second} {
+		if _, err := thunk(); !errors.Is(err, batchErr) {
+			t.Errorf("got %v, want %v", err, batchErr)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package loader
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/samthehai/chat/internal/interfaces/graph/loader/usecase"
+	resolverloader "github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
+)
+
+type loadersCtxKey struct{}
+
+// loadersHolder carries the bundle of the response in progress. gqlgen
+// resolves the events of a subscription with the context captured when the
+// operation started, so the bundle is swapped in place rather than put in a
+// new context.
+type loadersHolder struct {
+	loaders atomic.Value
+}
+
+// Loaders is the bundle of loaders of one GraphQL response.
+type Loaders struct {
+	user         *UserLoader
+	conversation *ConversationLoader
+	message      *MessageLoader
+	reaction     *ReactionLoader
+	presence     *PresenceLoader
+}
+
+// Factory builds a fresh bundle of loaders for every GraphQL response and
+// serves the one of the response in progress to the resolvers.
+type Factory struct {
+	userUsecase     usecase.UserUsecase
+	messageUsecase  usecase.MessageUsecase
+	presenceUsecase usecase.PresenceUsecase
+}
+
+func NewFactory(
+	userUsecase usecase.UserUsecase,
+	messageUsecase usecase.MessageUsecase,
+	presenceUsecase usecase.PresenceUsecase,
+) *Factory {
+	return &Factory{
+		userUsecase:     userUsecase,
+		messageUsecase:  messageUsecase,
+		presenceUsecase: presenceUsecase,
+	}
+}
+
+func (f *Factory) NewLoaders() *Loaders {
+	return &Loaders{
+		user:         NewUserLoader(f.userUsecase),
+		conversation: NewConversationLoader(f.messageUsecase),
+		message:      NewMessageLoader(f.messageUsecase),
+		reaction:     NewReactionLoader(f.messageUsecase),
+		presence:     NewPresenceLoader(f.presenceUsecase),
+	}
+}
+
+// AroundOperations is a gqlgen operation middleware putting the holder of
+// the bundles in the context of the operation, the one the events of a
+// subscription are resolved with.
+func (f *Factory) AroundOperations(ctx context.Context,
+	next graphql.OperationHandler) graphql.ResponseHandler {
+	return next(context.WithValue(ctx, loadersCtxKey{}, &loadersHolder{}))
+}
+
+// AroundResponses is a gqlgen response middleware giving a new bundle to
+// each response: once per query or mutation, once per event of a
+// subscription. gqlgen reads the next event only once the previous one is
+// written, so the swap never happens under a response being resolved.
+func (f *Factory) AroundResponses(ctx context.Context,
+	next graphql.ResponseHandler) *graphql.Response {
+	holder, ok := ctx.Value(loadersCtxKey{}).(*loadersHolder)
+	if !ok {
+		holder = &loadersHolder{}
+		ctx = context.WithValue(ctx, loadersCtxKey{}, holder)
+	}
+	holder.loaders.Store(f.NewLoaders())
+
+	return next(ctx)
+}
+
+func (f *Factory) UserLoader(ctx context.Context) resolverloader.UserLoader {
+	return f.fromContext(ctx).user
+}
+
+func (f *Factory) ConversationLoader(ctx context.Context) resolverloader.ConversationLoader {
+	return f.fromContext(ctx).conversation
+}
+
+func (f *Factory) MessageLoader(ctx context.Context) resolverloader.MessageLoader {
+	return f.fromContext(ctx).message
+}
+
+func (f *Factory) ReactionLoader(ctx context.Context) resolverloader.ReactionLoader {
+	return f.fromContext(ctx).reaction
+}
+
+func (f *Factory) PresenceLoader(ctx context.Context) resolverloader.PresenceLoader {
+	return f.fromContext(ctx).presence
+}
+
+// fromContext returns the bundle of the response in progress. Resolving
+// without one would silently give up batching, so it panics instead, which
+// gqlgen reports as an error of the field.
+func (f *Factory) fromContext(ctx context.Context) *Loaders {
+	if holder, ok := ctx.Value(loadersCtxKey{}).(*loadersHolder); ok {
+		if loaders, ok := holder.loaders.Load().(*Loaders); ok {
+			return loaders
+		}
+	}
+
+	panic("loader: no loaders in context, the factory must be registered with AroundOperations and AroundResponses")
+}
@@ -11,6 +11,10 @@ import (
 
 type MessageLoader struct {
 	messagesInConversationLoader *dataloader.Loader
+	repliesLoader                *dataloader.Loader
+	revisionsLoader              *dataloader.Loader
+	attachmentsLoader            *dataloader.Loader
+	linkPreviewsLoader           *dataloader.Loader
 }
 
 func NewMessageLoader(
@@ -20,7 +24,36 @@ func NewMessageLoader(
 		messagesInConversationLoader: newMessagesInConversationLoader(
 			messageFetcher.MessagesInConversations,
 		),
+		repliesLoader: newMessagesInConversationLoader(
+			messageFetcher.RepliesOfMessages,
+		),
+		revisionsLoader: newRevisionsLoader(
+			messageFetcher.RevisionsOfMessages,
+		),
+		attachmentsLoader: newAttachmentsLoader(
+			messageFetcher.AttachmentsOfMessages,
+		),
+		linkPreviewsLoader: newLinkPreviewsLoader(
+			messageFetcher.LinkPreviewsOfMessages,
+		),
+	}
+}
+
+func (l *MessageLoader) LoadRevisions(
+	ctx context.Context,
+	messageID entity.ID,
+) ([]*entity.MessageRevision, error) {
+	raw, err := l.revisionsLoader.Load(ctx, messageID)()
+	if err != nil {
+		return nil, fmt.Errorf("load revisions: id=%v, %w", messageID, err)
 	}
+
+	revisions, _ := raw.([]*entity.MessageRevision)
+	if revisions == nil {
+		revisions = []*entity.MessageRevision{}
+	}
+
+	return revisions, nil
 }
 
 func (l *MessageLoader) LoadMessagesInConversation(
@@ -35,6 +68,18 @@ func (l *MessageLoader) LoadMessagesInConversation(
 	return raw.(*entity.ConversationMessagesConnection), nil
 }
 
+func (l *MessageLoader) LoadRepliesOfMessage(
+	ctx context.Context,
+	input entity.RelayQueryInput,
+) (*entity.ConversationMessagesConnection, error) {
+	raw, err := l.repliesLoader.Load(ctx, input)()
+	if err != nil {
+		return nil, fmt.Errorf("load replies of message: input=%v, %w", input, err)
+	}
+
+	return raw.(*entity.ConversationMessagesConnection), nil
+}
+
 func newMessagesInConversationLoader(
 	fetchFunc func(ctx context.Context, inputs []entity.RelayQueryInput) (
 		map[entity.ID]*entity.ConversationMessagesConnection, error),
@@ -48,6 +93,7 @@ func newMessagesInConversationLoader(
 			}
 
 			mapMsgs, err := fetchFunc(ctx, inputs)
+			keyErrors, err := splitKeyErrors(err)
 			if err != nil {
 				return fillUpResultsWithError(len(keys), err)
 			}
@@ -58,7 +104,122 @@ func newMessagesInConversationLoader(
 
 				results = append(results, &dataloader.Result{
 					Data:  d,
-					Error: err,
+					Error: keyErrors[input.KeyID],
+				})
+			}
+
+			return results
+		},
+	)
+}
+
+func (l *MessageLoader) LoadAttachments(
+	ctx context.Context,
+	messageID entity.ID,
+) ([]*entity.Attachment, error) {
+	raw, err := l.attachmentsLoader.Load(ctx, messageID)()
+	if err != nil {
+		return nil, fmt.Errorf("load attachments: id=%v, %w", messageID, err)
+	}
+
+	attachments, _ := raw.([]*entity.Attachment)
+	if attachments == nil {
+		attachments = []*entity.Attachment{}
+	}
+
+	return attachments, nil
+}
+
+func (l *MessageLoader) LoadLinkPreviews(
+	ctx context.Context,
+	messageID entity.ID,
+) ([]*entity.LinkPreview, error) {
+	raw, err := l.linkPreviewsLoader.Load(ctx, messageID)()
+	if err != nil {
+		return nil, fmt.Errorf("load link previews: id=%v, %w", messageID, err)
+	}
+
+	previews, _ := raw.([]*entity.LinkPreview)
+	if previews == nil {
+		previews = []*entity.LinkPreview{}
+	}
+
+	return previews, nil
+}
+
+func newRevisionsLoader(
+	fetchFunc func(ctx context.Context,
+		messageIDs []entity.ID) (map[entity.ID][]*entity.MessageRevision, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			revisions, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  revisions[id],
+					Error: keyErrors[id],
+				})
+			}
+
+			return results
+		},
+	)
+}
+
+func newAttachmentsLoader(
+	fetchFunc func(ctx context.Context,
+		messageIDs []entity.ID) (map[entity.ID][]*entity.Attachment, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			attachments, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  attachments[id],
+					Error: keyErrors[id],
+				})
+			}
+
+			return results
+		},
+	)
+}
+
+func newLinkPreviewsLoader(
+	fetchFunc func(ctx context.Context,
+		messageIDs []entity.ID) (map[entity.ID][]*entity.LinkPreview, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			previews, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  previews[id],
+					Error: keyErrors[id],
 				})
 			}
 
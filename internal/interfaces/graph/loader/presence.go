@@ -0,0 +1,71 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/dataloader"
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/loader/usecase"
+)
+
+type PresenceLoader struct {
+	presenceLoader *dataloader.Loader
+}
+
+func NewPresenceLoader(
+	presenceFetcher usecase.PresenceUsecase,
+) *PresenceLoader {
+	return &PresenceLoader{
+		presenceLoader: newPresenceLoader(
+			presenceFetcher.Presences,
+		),
+	}
+}
+
+func (l *PresenceLoader) LoadPresence(
+	ctx context.Context,
+	userID entity.ID,
+) (*entity.Presence, error) {
+	raw, err := l.presenceLoader.Load(ctx, userID)()
+	if err != nil {
+		return nil, fmt.Errorf("load presence: id=%v, %w", userID, err)
+	}
+
+	presence, ok := raw.(*entity.Presence)
+	if !ok || presence == nil {
+		return &entity.Presence{
+			UserID: userID,
+			Status: entity.PresenceStatusOffline,
+		}, nil
+	}
+
+	return presence, nil
+}
+
+func newPresenceLoader(
+	fetchFunc func(ctx context.Context,
+		userIDs []entity.ID) (map[entity.ID]*entity.Presence, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			presences, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  presences[id],
+					Error: keyErrors[id],
+				})
+			}
+
+			return results
+		},
+	)
+}
@@ -0,0 +1,68 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/dataloader"
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/loader/usecase"
+)
+
+type ReactionLoader struct {
+	reactionsLoader *dataloader.Loader
+}
+
+func NewReactionLoader(
+	reactionFetcher usecase.MessageUsecase,
+) *ReactionLoader {
+	return &ReactionLoader{
+		reactionsLoader: newReactionsLoader(
+			reactionFetcher.ReactionsOfMessages,
+		),
+	}
+}
+
+func (l *ReactionLoader) LoadReactions(
+	ctx context.Context,
+	messageID entity.ID,
+) ([]*entity.ReactionGroup, error) {
+	raw, err := l.reactionsLoader.Load(ctx, messageID)()
+	if err != nil {
+		return nil, fmt.Errorf("load reactions: id=%v, %w", messageID, err)
+	}
+
+	reactions, _ := raw.([]*entity.ReactionGroup)
+	if reactions == nil {
+		reactions = []*entity.ReactionGroup{}
+	}
+
+	return reactions, nil
+}
+
+func newReactionsLoader(
+	fetchFunc func(ctx context.Context,
+		messageIDs []entity.ID) (map[entity.ID][]*entity.ReactionGroup, error),
+) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(
+		func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+			ids := getIDsFromKeys(keys)
+
+			reactions, err := fetchFunc(ctx, ids)
+			keyErrors, err := splitKeyErrors(err)
+			if err != nil {
+				return fillUpResultsWithError(len(keys), err)
+			}
+
+			results := make([]*dataloader.Result, 0, len(keys))
+			for _, id := range ids {
+				results = append(results, &dataloader.Result{
+					Data:  reactions[id],
+					Error: keyErrors[id],
+				})
+			}
+
+			return results
+		},
+	)
+}
@@ -14,8 +14,23 @@ type MessageUsecase interface {
 	GetConversationIDsFromUserIDs(ctx context.Context,
 		inputs []entity.RelayQueryInput) (map[entity.ID]*entity.IDsConnection, error)
 	GetParticipantsInConversations(ctx context.Context,
-		conversationIDs []entity.ID) (map[entity.ID][]*entity.User, error)
+		conversationIDs []entity.ID) (map[entity.ID][]*entity.Participant, error)
+	UnreadCountsOfConversations(ctx context.Context,
+		conversationIDs []entity.ID) (map[entity.ID]int, error)
+	ReadReceiptsInConversations(ctx context.Context,
+		conversationIDs []entity.ID) (map[entity.ID][]*entity.ReadReceipt, error)
 	MessagesInConversations(ctx context.Context,
 		inputs []entity.RelayQueryInput,
 	) (map[entity.ID]*entity.ConversationMessagesConnection, error)
+	RepliesOfMessages(ctx context.Context,
+		inputs []entity.RelayQueryInput,
+	) (map[entity.ID]*entity.ConversationMessagesConnection, error)
+	RevisionsOfMessages(ctx context.Context, messageIDs []entity.ID) (
+		map[entity.ID][]*entity.MessageRevision, error)
+	ReactionsOfMessages(ctx context.Context, messageIDs []entity.ID) (
+		map[entity.ID][]*entity.ReactionGroup, error)
+	AttachmentsOfMessages(ctx context.Context, messageIDs []entity.ID) (
+		map[entity.ID][]*entity.Attachment, error)
+	LinkPreviewsOfMessages(ctx context.Context, messageIDs []entity.ID) (
+		map[entity.ID][]*entity.LinkPreview, error)
 }
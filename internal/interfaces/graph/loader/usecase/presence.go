@@ -0,0 +1,12 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type PresenceUsecase interface {
+	Presences(ctx context.Context,
+		userIDs []entity.ID) (map[entity.ID]*entity.Presence, error)
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+// AttachmentsEndpoint serves the content of the attachments to the
+// participants of their conversation.
+const AttachmentsEndpoint = "/attachments"
+
+const (
+	// AttachmentURLTTL is the longest an attachment URL stays valid.
+	AttachmentURLTTL = 15 * time.Minute
+	// attachmentURLGranularity rounds the expiries down, so that an
+	// attachment keeps the same URL for a while and browsers reuse their
+	// cached copy.
+	attachmentURLGranularity = 5 * time.Minute
+	attachmentSignatureSize  = 16
+)
+
+// AttachmentURLSigner hands out the URLs of the attachments. Browsers load
+// them without the Authorization header, e.g. in an img element, so each URL
+// carries the viewer it was issued to, an expiry and a signature of both.
+type AttachmentURLSigner struct {
+	secret []byte
+}
+
+func NewAttachmentURLSigner(secret string) *AttachmentURLSigner {
+	return &AttachmentURLSigner{secret: []byte(secret)}
+}
+
+// AttachmentURL returns the URL of the content of the attachment for the
+// viewer, who is the user ID of an auth token.
+func (s *AttachmentURLSigner) AttachmentURL(viewer string, attachmentID entity.ID,
+	now time.Time) string {
+	return s.signedURL(fmt.Sprintf("%s/%v", AttachmentsEndpoint, attachmentID), viewer, now)
+}
+
+func (s *AttachmentURLSigner) AttachmentThumbnailURL(viewer string, attachmentID entity.ID,
+	now time.Time) string {
+	return s.signedURL(fmt.Sprintf("%s/%v/thumbnail", AttachmentsEndpoint, attachmentID), viewer, now)
+}
+
+// Verify checks the URL of the request and returns the viewer it was issued
+// to.
+func (s *AttachmentURLSigner) Verify(path string, query url.Values, now time.Time) (string, error) {
+	viewer := query.Get("viewer")
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || viewer == "" {
+		return "", fmt.Errorf("malformed attachment url: %w", domainerrors.ErrInvalid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, s.sign(path, viewer, expires)) {
+		return "", fmt.Errorf("attachment url signature mismatch: %w", domainerrors.ErrInvalid)
+	}
+
+	if now.Unix() > expires {
+		return "", fmt.Errorf("attachment url expired: %w", domainerrors.ErrInvalid)
+	}
+
+	return viewer, nil
+}
+
+func (s *AttachmentURLSigner) signedURL(path, viewer string, now time.Time) string {
+	expires := now.Add(AttachmentURLTTL).Truncate(attachmentURLGranularity).Unix()
+
+	query := url.Values{}
+	query.Set("viewer", viewer)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", base64.RawURLEncoding.EncodeToString(s.sign(path, viewer, expires)))
+
+	return path + "?" + query.Encode()
+}
+
+func (s *AttachmentURLSigner) sign(path, viewer string, expires int64) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%d", path, viewer, expires)
+
+	return mac.Sum(nil)[:attachmentSignatureSize]
+}
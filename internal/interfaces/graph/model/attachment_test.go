@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	domainerrors "github.com/samthehai/chat/internal/domain/errors"
+)
+
+func parseAttachmentURL(t *testing.T, rawURL string) (string, url.Values) {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parse %v: %v", rawURL, err)
+	}
+
+	return u.Path, u.Query()
+}
+
+func TestAttachmentURLSigner(t *testing.T) {
+	signer := NewAttachmentURLSigner("secret")
+	now := time.Date(2021, 6, 24, 9, 1, 0, 0, time.UTC)
+
+	path, query := parseAttachmentURL(t, signer.AttachmentURL("firebase-uid", 42, now))
+	if path != "/attachments/42" {
+		t.Fatalf("got path %q, want /attachments/42", path)
+	}
+
+	viewer, err := signer.Verify(path, query, now)
+	if err != nil || viewer != "firebase-uid" {
+		t.Fatalf("got %q, %v, want the viewer", viewer, err)
+	}
+
+	// URLs issued a moment apart are the same, browsers cache them
+	if a, b := signer.AttachmentURL("firebase-uid", 42, now),
+		signer.AttachmentURL("firebase-uid", 42, now.Add(time.Minute)); a != b {
+		t.Errorf("got %q and %q, want the same URL", a, b)
+	}
+
+	thumbnailPath, thumbnailQuery := parseAttachmentURL(t, signer.AttachmentThumbnailURL("firebase-uid", 42, now))
+	if !strings.HasSuffix(thumbnailPath, "/42/thumbnail") {
+		t.Errorf("got path %q, want the thumbnail", thumbnailPath)
+	}
+
+	tampered := func(key, value string) url.Values {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set(key, value)
+
+		return q
+	}
+
+	refused := []struct {
+		name   string
+		signer *AttachmentURLSigner
+		path   string
+		query  url.Values
+		now    time.Time
+	}{
+		{"other attachment", signer, "/attachments/43", query, now},
+		{"thumbnail signature on content", signer, path, thumbnailQuery, now},
+		{"other viewer", signer, path, tampered("viewer", "other-uid"), now},
+		{"extended expiry", signer, path, tampered("expires", "4102444800"), now},
+		{"bad signature", signer, path, tampered("signature", "AAAA"), now},
+		{"no signature", signer, path, url.Values{"viewer": {"firebase-uid"}}, now},
+		{"expired", signer, path, query, now.Add(AttachmentURLTTL)},
+		{"other secret", NewAttachmentURLSigner("other"), path, query, now},
+	}
+
+	for _, tt := range refused {
+		if _, err := tt.signer.Verify(tt.path, tt.query, tt.now); !errors.Is(err, domainerrors.ErrInvalid) {
+			t.Errorf("%v: got %v, want %v", tt.name, err, domainerrors.ErrInvalid)
+		}
+	}
+}
@@ -15,8 +15,9 @@ type CreateNewConversationPayload struct {
 }
 
 type PostMessageInput struct {
-	ConversationID entity.ID `json:"conversationId"`
-	Text           string    `json:"text"`
+	ConversationID entity.ID  `json:"conversationId"`
+	Text           string     `json:"text"`
+	ReplyToID      *entity.ID `json:"replyToId"`
 }
 
 type PostMessagePayload struct {
@@ -1,3 +1,130 @@
 // Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
 
 package model
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type AddParticipantsInput struct {
+	ConversationID entity.ID   `json:"conversationId"`
+	UserIds        []entity.ID `json:"userIds"`
+}
+
+type AddParticipantsPayload struct {
+	Conversation *entity.Conversation `json:"conversation"`
+}
+
+type ChangeParticipantRoleInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+	UserID         entity.ID `json:"userId"`
+}
+
+type ChangeParticipantRolePayload struct {
+	Conversation *entity.Conversation `json:"conversation"`
+}
+
+type DeleteConversationInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+}
+
+type DeleteConversationPayload struct {
+	ConversationID entity.ID `json:"conversationId"`
+}
+
+type DeleteMessageInput struct {
+	MessageID entity.ID `json:"messageId"`
+}
+
+type DeleteMessagePayload struct {
+	Message *entity.Message `json:"message"`
+}
+
+type EditMessageInput struct {
+	MessageID entity.ID `json:"messageId"`
+	Text      string    `json:"text"`
+}
+
+type EditMessagePayload struct {
+	Message *entity.Message `json:"message"`
+}
+
+type FriendshipInput struct {
+	UserID entity.ID `json:"userId"`
+}
+
+type FriendshipPayload struct {
+	Friendship *entity.Friendship `json:"friendship"`
+}
+
+type LeaveConversationInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+}
+
+type LeaveConversationPayload struct {
+	ConversationID entity.ID `json:"conversationId"`
+}
+
+type MarkConversationReadInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+	MessageID      entity.ID `json:"messageId"`
+}
+
+type MarkConversationReadPayload struct {
+	Conversation *entity.Conversation `json:"conversation"`
+}
+
+type PostAttachmentInput struct {
+	ConversationID entity.ID      `json:"conversationId"`
+	File           graphql.Upload `json:"file"`
+	Text           string         `json:"text"`
+	ReplyToID      *entity.ID     `json:"replyToId"`
+}
+
+type PostAttachmentPayload struct {
+	Message *entity.Message `json:"message"`
+}
+
+type ReactionInput struct {
+	MessageID entity.ID `json:"messageId"`
+	Emoji     string    `json:"emoji"`
+}
+
+type ReactionPayload struct {
+	Message *entity.Message `json:"message"`
+}
+
+type RemoveParticipantInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+	UserID         entity.ID `json:"userId"`
+}
+
+type RemoveParticipantPayload struct {
+	Conversation *entity.Conversation `json:"conversation"`
+}
+
+type RenameConversationInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+	Title          string    `json:"title"`
+}
+
+type RenameConversationPayload struct {
+	Conversation *entity.Conversation `json:"conversation"`
+}
+
+type SetPresenceInput struct {
+	Status entity.PresenceStatus `json:"status"`
+}
+
+type SetPresencePayload struct {
+	Presence *entity.Presence `json:"presence"`
+}
+
+type TypingInput struct {
+	ConversationID entity.ID `json:"conversationId"`
+}
+
+type TypingPayload struct {
+	Typing *entity.TypingEvent `json:"typing"`
+}
@@ -0,0 +1,56 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/model"
+	"github.com/samthehai/chat/internal/interfaces/graph/resolver/usecase"
+)
+
+type AttachmentResolver struct {
+	userUsecase usecase.UserUsecase
+	signer      *model.AttachmentURLSigner
+}
+
+func NewAttachmentResolver(
+	userUsecase usecase.UserUsecase,
+	signer *model.AttachmentURLSigner,
+) *AttachmentResolver {
+	return &AttachmentResolver{
+		userUsecase: userUsecase,
+		signer:      signer,
+	}
+}
+
+func (r *AttachmentResolver) URL(
+	ctx context.Context,
+	obj *entity.Attachment,
+) (string, error) {
+	token, err := r.userUsecase.GetAuthTokenFromContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get auth token from context: %w", err)
+	}
+
+	return r.signer.AttachmentURL(token.UserID, obj.ID, time.Now()), nil
+}
+
+func (r *AttachmentResolver) ThumbnailURL(
+	ctx context.Context,
+	obj *entity.Attachment,
+) (*string, error) {
+	if obj.ThumbnailKey == nil {
+		return nil, nil
+	}
+
+	token, err := r.userUsecase.GetAuthTokenFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth token from context: %w", err)
+	}
+
+	url := r.signer.AttachmentThumbnailURL(token.UserID, obj.ID, time.Now())
+
+	return &url, nil
+}
@@ -9,20 +9,12 @@ import (
 )
 
 type ConversationResolver struct {
-	messageLoader      loader.MessageLoader
-	userLoader         loader.UserLoader
-	conversationLoader loader.ConversationLoader
+	loaders loader.Provider
 }
 
-func NewConversationResolver(
-	messageLoader loader.MessageLoader,
-	userLoader loader.UserLoader,
-	conversationLoader loader.ConversationLoader,
-) *ConversationResolver {
+func NewConversationResolver(loaders loader.Provider) *ConversationResolver {
 	return &ConversationResolver{
-		messageLoader:      messageLoader,
-		userLoader:         userLoader,
-		conversationLoader: conversationLoader,
+		loaders: loaders,
 	}
 }
 
@@ -30,7 +22,7 @@ func (r *ConversationResolver) Creator(
 	ctx context.Context,
 	obj *entity.Conversation,
 ) (*entity.User, error) {
-	creator, err := r.userLoader.LoadUser(ctx, *obj.CreatorID)
+	creator, err := r.loaders.UserLoader(ctx).LoadUser(ctx, *obj.CreatorID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load user: %w", err)
 	}
@@ -41,20 +33,23 @@ func (r *ConversationResolver) Creator(
 func (r *ConversationResolver) Messages(
 	ctx context.Context,
 	obj *entity.Conversation,
-	first int,
-	after entity.ID,
+	first *int,
+	after *entity.Cursor,
+	last *int,
+	before *entity.Cursor,
 	sortBy entity.MessagesSortByType,
 	sortOrder entity.SortOrderType,
 ) (*entity.ConversationMessagesConnection, error) {
-	msgs, err := r.messageLoader.LoadMessagesInConversation(ctx,
+	input, err := entity.NewListQueryInput(first, after, last, before,
+		string(sortBy), sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("new list query input: %w", err)
+	}
+
+	msgs, err := r.loaders.MessageLoader(ctx).LoadMessagesInConversation(ctx,
 		entity.RelayQueryInput{
-			KeyID: obj.ID,
-			ListQueryInput: entity.ListQueryInput{
-				First:     first,
-				After:     after,
-				SortBy:    string(sortBy),
-				SortOrder: sortOrder,
-			},
+			KeyID:          obj.ID,
+			ListQueryInput: input,
 		})
 	if err != nil {
 		return nil, fmt.Errorf("load messages in conversation: %w", err)
@@ -66,11 +61,97 @@ func (r *ConversationResolver) Messages(
 func (r *ConversationResolver) Participants(
 	ctx context.Context,
 	obj *entity.Conversation,
-) ([]*entity.User, error) {
-	pp, err := r.conversationLoader.LoadParticipantsInConversation(ctx, obj.ID)
+) ([]*entity.Participant, error) {
+	pp, err := r.loaders.ConversationLoader(ctx).LoadParticipantsInConversation(ctx, obj.ID)
 	if err != nil {
 		return nil, fmt.Errorf("load participants in conversation: %w", err)
 	}
 
 	return pp, nil
 }
+
+func (r *ConversationResolver) UnreadCount(
+	ctx context.Context,
+	obj *entity.Conversation,
+) (int, error) {
+	count, err := r.loaders.ConversationLoader(ctx).LoadUnreadCount(ctx, obj.ID)
+	if err != nil {
+		return 0, fmt.Errorf("load unread count: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *ConversationResolver) ReadBy(
+	ctx context.Context,
+	obj *entity.Conversation,
+) ([]*entity.ReadReceipt, error) {
+	receipts, err := r.loaders.ConversationLoader(ctx).LoadReadReceipts(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load read receipts: %w", err)
+	}
+
+	return receipts, nil
+}
+
+type ReadReceiptResolver struct {
+	loaders loader.Provider
+}
+
+func NewReadReceiptResolver(loaders loader.Provider) *ReadReceiptResolver {
+	return &ReadReceiptResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *ReadReceiptResolver) User(
+	ctx context.Context,
+	obj *entity.ReadReceipt,
+) (*entity.User, error) {
+	user, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+
+	return user, nil
+}
+
+type ConversationEventResolver struct {
+	loaders loader.Provider
+}
+
+func NewConversationEventResolver(loaders loader.Provider) *ConversationEventResolver {
+	return &ConversationEventResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *ConversationEventResolver) Actor(
+	ctx context.Context,
+	obj *entity.ConversationEvent,
+) (*entity.User, error) {
+	actor, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.ActorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	return actor, nil
+}
+
+func (r *ConversationEventResolver) Users(
+	ctx context.Context,
+	obj *entity.ConversationEvent,
+) ([]*entity.User, error) {
+	users := make([]*entity.User, 0, len(obj.UserIDs))
+
+	for _, id := range obj.UserIDs {
+		user, err := r.loaders.UserLoader(ctx).LoadUser(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load user: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,53 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
+)
+
+type FriendshipResolver struct {
+	loaders loader.Provider
+}
+
+func NewFriendshipResolver(loaders loader.Provider) *FriendshipResolver {
+	return &FriendshipResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *FriendshipResolver) Friend(
+	ctx context.Context,
+	obj *entity.Friendship,
+) (*entity.User, error) {
+	friend, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.FriendID)
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+
+	return friend, nil
+}
+
+type FriendRequestResolver struct {
+	loaders loader.Provider
+}
+
+func NewFriendRequestResolver(loaders loader.Provider) *FriendRequestResolver {
+	return &FriendRequestResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *FriendRequestResolver) Sender(
+	ctx context.Context,
+	obj *entity.FriendRequest,
+) (*entity.User, error) {
+	sender, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.SenderID)
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+
+	return sender, nil
+}
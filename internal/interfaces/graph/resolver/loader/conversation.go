@@ -12,5 +12,8 @@ type ConversationLoader interface {
 	LoadConversationIDsFromUser(ctx context.Context,
 		input entity.RelayQueryInput) (*entity.IDsConnection, error)
 	LoadParticipantsInConversation(ctx context.Context,
-		conversationID entity.ID) ([]*entity.User, error)
+		conversationID entity.ID) ([]*entity.Participant, error)
+	LoadUnreadCount(ctx context.Context, conversationID entity.ID) (int, error)
+	LoadReadReceipts(ctx context.Context,
+		conversationID entity.ID) ([]*entity.ReadReceipt, error)
 }
@@ -11,4 +11,20 @@ type MessageLoader interface {
 		ctx context.Context,
 		input entity.RelayQueryInput,
 	) (*entity.ConversationMessagesConnection, error)
+	LoadRepliesOfMessage(
+		ctx context.Context,
+		input entity.RelayQueryInput,
+	) (*entity.ConversationMessagesConnection, error)
+	LoadRevisions(
+		ctx context.Context,
+		messageID entity.ID,
+	) ([]*entity.MessageRevision, error)
+	LoadAttachments(
+		ctx context.Context,
+		messageID entity.ID,
+	) ([]*entity.Attachment, error)
+	LoadLinkPreviews(
+		ctx context.Context,
+		messageID entity.ID,
+	) ([]*entity.LinkPreview, error)
 }
@@ -0,0 +1,11 @@
+package loader
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type PresenceLoader interface {
+	LoadPresence(ctx context.Context, userID entity.ID) (*entity.Presence, error)
+}
@@ -0,0 +1,15 @@
+package loader
+
+import (
+	"context"
+)
+
+// Provider hands out the loaders of the operation carried by ctx, so that
+// their caches never outlive it.
+type Provider interface {
+	UserLoader(ctx context.Context) UserLoader
+	ConversationLoader(ctx context.Context) ConversationLoader
+	MessageLoader(ctx context.Context) MessageLoader
+	ReactionLoader(ctx context.Context) ReactionLoader
+	PresenceLoader(ctx context.Context) PresenceLoader
+}
@@ -0,0 +1,14 @@
+package loader
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type ReactionLoader interface {
+	LoadReactions(
+		ctx context.Context,
+		messageID entity.ID,
+	) ([]*entity.ReactionGroup, error)
+}
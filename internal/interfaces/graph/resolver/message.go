@@ -9,17 +9,12 @@ import (
 )
 
 type MessageResolver struct {
-	userLoader         loader.UserLoader
-	conversationLoader loader.ConversationLoader
+	loaders loader.Provider
 }
 
-func NewMessageResolver(
-	userLoader loader.UserLoader,
-	conversationLoader loader.ConversationLoader,
-) *MessageResolver {
+func NewMessageResolver(loaders loader.Provider) *MessageResolver {
 	return &MessageResolver{
-		userLoader:         userLoader,
-		conversationLoader: conversationLoader,
+		loaders: loaders,
 	}
 }
 
@@ -27,7 +22,7 @@ func (r *MessageResolver) Sender(
 	ctx context.Context,
 	obj *entity.Message,
 ) (*entity.User, error) {
-	sender, err := r.userLoader.LoadUser(ctx, obj.SenderID)
+	sender, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.SenderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load user: %w", err)
 	}
@@ -39,10 +34,95 @@ func (r *MessageResolver) Conversation(
 	ctx context.Context,
 	obj *entity.Message,
 ) (*entity.Conversation, error) {
-	c, err := r.conversationLoader.LoadConversation(ctx, obj.ConversationID)
+	c, err := r.loaders.ConversationLoader(ctx).LoadConversation(ctx, obj.ConversationID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load conversation: %w", err)
 	}
 
 	return c, nil
 }
+
+func (r *MessageResolver) Revisions(
+	ctx context.Context,
+	obj *entity.Message,
+) ([]*entity.MessageRevision, error) {
+	revisions, err := r.loaders.MessageLoader(ctx).LoadRevisions(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *MessageResolver) Attachments(
+	ctx context.Context,
+	obj *entity.Message,
+) ([]*entity.Attachment, error) {
+	// only image and file messages carry attachments
+	if obj.Type == entity.MessageTypeText {
+		return []*entity.Attachment{}, nil
+	}
+
+	attachments, err := r.loaders.MessageLoader(ctx).LoadAttachments(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+func (r *MessageResolver) LinkPreviews(
+	ctx context.Context,
+	obj *entity.Message,
+) ([]*entity.LinkPreview, error) {
+	if len(entity.ExtractLinks(obj.Content)) == 0 {
+		return []*entity.LinkPreview{}, nil
+	}
+
+	previews, err := r.loaders.MessageLoader(ctx).LoadLinkPreviews(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load link previews: %w", err)
+	}
+
+	return previews, nil
+}
+
+func (r *MessageResolver) Reactions(
+	ctx context.Context,
+	obj *entity.Message,
+) ([]*entity.ReactionGroup, error) {
+	reactions, err := r.loaders.ReactionLoader(ctx).LoadReactions(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reactions: %w", err)
+	}
+
+	return reactions, nil
+}
+
+func (r *MessageResolver) Replies(
+	ctx context.Context,
+	obj *entity.Message,
+	first *int,
+	after *entity.Cursor,
+	last *int,
+	before *entity.Cursor,
+	sortBy entity.MessagesSortByType,
+	sortOrder entity.SortOrderType,
+) (*entity.ConversationMessagesConnection, error) {
+	input, err := entity.NewListQueryInput(first, after, last, before,
+		string(sortBy), sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("new list query input: %w", err)
+	}
+
+	replies, err := r.loaders.MessageLoader(ctx).LoadRepliesOfMessage(ctx,
+		entity.RelayQueryInput{
+			KeyID:          obj.ID,
+			ListQueryInput: input,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("load replies of message: %w", err)
+	}
+
+	return replies, nil
+}
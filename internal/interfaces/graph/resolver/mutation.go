@@ -10,17 +10,23 @@ import (
 )
 
 type MutationResolver struct {
-	messageUsecase usecase.MessageUsecase
-	userUsecase    usecase.UserUsecase
+	messageUsecase    usecase.MessageUsecase
+	userUsecase       usecase.UserUsecase
+	friendshipUsecase usecase.FriendshipUsecase
+	presenceUsecase   usecase.PresenceUsecase
 }
 
 func NewMutationResolver(
 	messageUsecase usecase.MessageUsecase,
 	userUsecase usecase.UserUsecase,
+	friendshipUsecase usecase.FriendshipUsecase,
+	presenceUsecase usecase.PresenceUsecase,
 ) *MutationResolver {
 	return &MutationResolver{
-		messageUsecase: messageUsecase,
-		userUsecase:    userUsecase,
+		messageUsecase:    messageUsecase,
+		userUsecase:       userUsecase,
+		friendshipUsecase: friendshipUsecase,
+		presenceUsecase:   presenceUsecase,
 	}
 }
 
@@ -57,7 +63,8 @@ func (r *MutationResolver) PostMessage(ctx context.Context, input model.PostMess
 		return nil, fmt.Errorf("failed to get user from context: user is nil")
 	}
 
-	message, err := r.messageUsecase.PostMessage(ctx, input.ConversationID, entity.MessageTypeText, user.ID, input.Text)
+	message, err := r.messageUsecase.PostMessage(ctx, input.ConversationID, entity.MessageTypeText, user.ID, input.Text,
+		input.ReplyToID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to post message: %w", err)
 	}
@@ -67,6 +74,334 @@ func (r *MutationResolver) PostMessage(ctx context.Context, input model.PostMess
 	}, nil
 }
 
+func (r *MutationResolver) PostAttachment(ctx context.Context, input model.PostAttachmentInput) (*model.PostAttachmentPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	message, err := r.messageUsecase.PostAttachment(ctx, input.ConversationID, user.ID,
+		entity.FileUpload{
+			FileName: input.File.Filename,
+			Size:     input.File.Size,
+			Content:  input.File.File,
+		}, input.Text, input.ReplyToID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post attachment: %w", err)
+	}
+
+	return &model.PostAttachmentPayload{
+		Message: message,
+	}, nil
+}
+
+func (r *MutationResolver) EditMessage(ctx context.Context, input model.EditMessageInput) (*model.EditMessagePayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	message, err := r.messageUsecase.EditMessage(ctx, user.ID, input.MessageID, input.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	return &model.EditMessagePayload{
+		Message: message,
+	}, nil
+}
+
+func (r *MutationResolver) DeleteMessage(ctx context.Context, input model.DeleteMessageInput) (*model.DeleteMessagePayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	message, err := r.messageUsecase.DeleteMessage(ctx, user.ID, input.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	return &model.DeleteMessagePayload{
+		Message: message,
+	}, nil
+}
+
+func (r *MutationResolver) AddReaction(ctx context.Context, input model.ReactionInput) (*model.ReactionPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	message, err := r.messageUsecase.AddReaction(ctx, user.ID, input.MessageID, input.Emoji)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	return &model.ReactionPayload{
+		Message: message,
+	}, nil
+}
+
+func (r *MutationResolver) RemoveReaction(ctx context.Context, input model.ReactionInput) (*model.ReactionPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	message, err := r.messageUsecase.RemoveReaction(ctx, user.ID, input.MessageID, input.Emoji)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	return &model.ReactionPayload{
+		Message: message,
+	}, nil
+}
+
+func (r *MutationResolver) StartTyping(ctx context.Context, input model.TypingInput) (*model.TypingPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	typing, err := r.messageUsecase.StartTyping(ctx, user.ID, input.ConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start typing: %w", err)
+	}
+
+	return &model.TypingPayload{
+		Typing: typing,
+	}, nil
+}
+
+func (r *MutationResolver) StopTyping(ctx context.Context, input model.TypingInput) (*model.TypingPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	typing, err := r.messageUsecase.StopTyping(ctx, user.ID, input.ConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop typing: %w", err)
+	}
+
+	return &model.TypingPayload{
+		Typing: typing,
+	}, nil
+}
+
+func (r *MutationResolver) SetPresence(ctx context.Context, input model.SetPresenceInput) (*model.SetPresencePayload, error) {
+	presence, err := r.presenceUsecase.SetPresence(ctx, input.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set presence: %w", err)
+	}
+
+	return &model.SetPresencePayload{
+		Presence: presence,
+	}, nil
+}
+
+func (r *MutationResolver) MarkConversationRead(ctx context.Context, input model.MarkConversationReadInput) (*model.MarkConversationReadPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	conversation, err := r.messageUsecase.MarkConversationRead(ctx, user.ID, input.ConversationID,
+		input.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark conversation read: %w", err)
+	}
+
+	return &model.MarkConversationReadPayload{
+		Conversation: conversation,
+	}, nil
+}
+
+func (r *MutationResolver) RenameConversation(ctx context.Context, input model.RenameConversationInput) (*model.RenameConversationPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	conversation, err := r.messageUsecase.RenameConversation(ctx, user.ID, input.ConversationID,
+		input.Title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename conversation: %w", err)
+	}
+
+	return &model.RenameConversationPayload{
+		Conversation: conversation,
+	}, nil
+}
+
+func (r *MutationResolver) AddParticipants(ctx context.Context, input model.AddParticipantsInput) (*model.AddParticipantsPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	conversation, err := r.messageUsecase.AddParticipants(ctx, user.ID, input.ConversationID,
+		input.UserIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add participants: %w", err)
+	}
+
+	return &model.AddParticipantsPayload{
+		Conversation: conversation,
+	}, nil
+}
+
+func (r *MutationResolver) RemoveParticipant(ctx context.Context, input model.RemoveParticipantInput) (*model.RemoveParticipantPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	conversation, err := r.messageUsecase.RemoveParticipant(ctx, user.ID, input.ConversationID,
+		input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	return &model.RemoveParticipantPayload{
+		Conversation: conversation,
+	}, nil
+}
+
+func (r *MutationResolver) PromoteParticipant(ctx context.Context, input model.ChangeParticipantRoleInput) (*model.ChangeParticipantRolePayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	conversation, err := r.messageUsecase.PromoteParticipant(ctx, user.ID, input.ConversationID,
+		input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to promote participant: %w", err)
+	}
+
+	return &model.ChangeParticipantRolePayload{
+		Conversation: conversation,
+	}, nil
+}
+
+func (r *MutationResolver) DemoteParticipant(ctx context.Context, input model.ChangeParticipantRoleInput) (*model.ChangeParticipantRolePayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	conversation, err := r.messageUsecase.DemoteParticipant(ctx, user.ID, input.ConversationID,
+		input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to demote participant: %w", err)
+	}
+
+	return &model.ChangeParticipantRolePayload{
+		Conversation: conversation,
+	}, nil
+}
+
+func (r *MutationResolver) LeaveConversation(ctx context.Context, input model.LeaveConversationInput) (*model.LeaveConversationPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	if err := r.messageUsecase.LeaveConversation(ctx, user.ID, input.ConversationID); err != nil {
+		return nil, fmt.Errorf("failed to leave conversation: %w", err)
+	}
+
+	return &model.LeaveConversationPayload{
+		ConversationID: input.ConversationID,
+	}, nil
+}
+
+func (r *MutationResolver) DeleteConversation(ctx context.Context, input model.DeleteConversationInput) (*model.DeleteConversationPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	if err := r.messageUsecase.DeleteConversation(ctx, user.ID, input.ConversationID); err != nil {
+		return nil, fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	return &model.DeleteConversationPayload{
+		ConversationID: input.ConversationID,
+	}, nil
+}
+
 func (r *MutationResolver) Login(ctx context.Context) (*entity.User, error) {
 	return r.userUsecase.Login(ctx)
 }
+
+func (r *MutationResolver) SendFriendRequest(ctx context.Context, input model.FriendshipInput) (*model.FriendshipPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	friendship, err := r.friendshipUsecase.SendFriendRequest(ctx, user.ID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send friend request: %w", err)
+	}
+
+	return &model.FriendshipPayload{Friendship: friendship}, nil
+}
+
+func (r *MutationResolver) AcceptFriendRequest(ctx context.Context, input model.FriendshipInput) (*model.FriendshipPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	friendship, err := r.friendshipUsecase.AcceptFriendRequest(ctx, user.ID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept friend request: %w", err)
+	}
+
+	return &model.FriendshipPayload{Friendship: friendship}, nil
+}
+
+func (r *MutationResolver) DeclineFriendRequest(ctx context.Context, input model.FriendshipInput) (*model.FriendshipPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	if err := r.friendshipUsecase.DeclineFriendRequest(ctx, user.ID, input.UserID); err != nil {
+		return nil, fmt.Errorf("failed to decline friend request: %w", err)
+	}
+
+	return &model.FriendshipPayload{}, nil
+}
+
+func (r *MutationResolver) RemoveFriend(ctx context.Context, input model.FriendshipInput) (*model.FriendshipPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	if err := r.friendshipUsecase.RemoveFriend(ctx, user.ID, input.UserID); err != nil {
+		return nil, fmt.Errorf("failed to remove friend: %w", err)
+	}
+
+	return &model.FriendshipPayload{}, nil
+}
+
+func (r *MutationResolver) BlockUser(ctx context.Context, input model.FriendshipInput) (*model.FriendshipPayload, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	friendship, err := r.friendshipUsecase.BlockUser(ctx, user.ID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to block user: %w", err)
+	}
+
+	return &model.FriendshipPayload{Friendship: friendship}, nil
+}
@@ -0,0 +1,31 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
+)
+
+type PresenceResolver struct {
+	loaders loader.Provider
+}
+
+func NewPresenceResolver(loaders loader.Provider) *PresenceResolver {
+	return &PresenceResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *PresenceResolver) User(
+	ctx context.Context,
+	obj *entity.Presence,
+) (*entity.User, error) {
+	user, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+
+	return user, nil
+}
@@ -2,26 +2,85 @@ package resolver
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/samthehai/chat/internal/domain/entity"
 	"github.com/samthehai/chat/internal/interfaces/graph/resolver/usecase"
 )
 
 type QueryResolver struct {
-	messageUsecase usecase.MessageUsecase
-	userUsecase    usecase.UserUsecase
+	messageUsecase    usecase.MessageUsecase
+	userUsecase       usecase.UserUsecase
+	friendshipUsecase usecase.FriendshipUsecase
 }
 
 func NewQueryResolver(
 	messageUsecase usecase.MessageUsecase,
 	userUsecase usecase.UserUsecase,
+	friendshipUsecase usecase.FriendshipUsecase,
 ) *QueryResolver {
 	return &QueryResolver{
-		messageUsecase: messageUsecase,
-		userUsecase:    userUsecase,
+		messageUsecase:    messageUsecase,
+		userUsecase:       userUsecase,
+		friendshipUsecase: friendshipUsecase,
 	}
 }
 
 func (r *QueryResolver) Me(ctx context.Context) (*entity.User, error) {
 	return r.userUsecase.Me(ctx)
 }
+
+func (r *QueryResolver) PendingFriendRequests(ctx context.Context) ([]*entity.FriendRequest, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	requests, err := r.friendshipUsecase.PendingFriendRequests(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending friend requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+func (r *QueryResolver) SearchMessages(
+	ctx context.Context,
+	query string,
+	conversationID *entity.ID,
+	senderID *entity.ID,
+	sentBefore *time.Time,
+	sentAfter *time.Time,
+	first *int,
+	after *entity.Cursor,
+	last *int,
+	before *entity.Cursor,
+	sortBy entity.SearchMessagesSortByType,
+	sortOrder entity.SortOrderType,
+) (*entity.MessageSearchConnection, error) {
+	user, err := r.userUsecase.GetUserFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user from context: %w", err)
+	}
+
+	input, err := entity.NewListQueryInput(first, after, last, before,
+		string(sortBy), sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("new list query input: %w", err)
+	}
+
+	res, err := r.messageUsecase.SearchMessages(ctx, user.ID, entity.MessageSearchQuery{
+		Text:           query,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		SentBefore:     sentBefore,
+		SentAfter:      sentAfter,
+		ListQueryInput: input,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,31 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
+)
+
+type ReactionEventResolver struct {
+	loaders loader.Provider
+}
+
+func NewReactionEventResolver(loaders loader.Provider) *ReactionEventResolver {
+	return &ReactionEventResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *ReactionEventResolver) User(
+	ctx context.Context,
+	obj *entity.ReactionEvent,
+) (*entity.User, error) {
+	user, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+
+	return user, nil
+}
@@ -3,12 +3,20 @@ package resolver
 import "github.com/samthehai/chat/internal/interfaces/graph/generated"
 
 type Resolver struct {
-	query        generated.QueryResolver
-	mutation     generated.MutationResolver
-	subscription generated.SubscriptionResolver
-	message      generated.MessageResolver
-	conversation generated.ConversationResolver
-	user         generated.UserResolver
+	query             generated.QueryResolver
+	mutation          generated.MutationResolver
+	subscription      generated.SubscriptionResolver
+	message           generated.MessageResolver
+	conversation      generated.ConversationResolver
+	conversationEvent generated.ConversationEventResolver
+	user              generated.UserResolver
+	friendship        generated.FriendshipResolver
+	friendRequest     generated.FriendRequestResolver
+	reactionEvent     generated.ReactionEventResolver
+	readReceipt       generated.ReadReceiptResolver
+	typingEvent       generated.TypingEventResolver
+	presence          generated.PresenceResolver
+	attachment        generated.AttachmentResolver
 }
 
 func NewResolver(
@@ -17,15 +25,31 @@ func NewResolver(
 	subscription *SubscriptionResolver,
 	message *MessageResolver,
 	conversation *ConversationResolver,
+	conversationEvent *ConversationEventResolver,
 	user *UserResolver,
+	friendship *FriendshipResolver,
+	friendRequest *FriendRequestResolver,
+	reactionEvent *ReactionEventResolver,
+	readReceipt *ReadReceiptResolver,
+	typingEvent *TypingEventResolver,
+	presence *PresenceResolver,
+	attachment *AttachmentResolver,
 ) Resolver {
 	return Resolver{
-		query:        query,
-		mutation:     mutation,
-		subscription: subscription,
-		message:      message,
-		conversation: conversation,
-		user:         user,
+		query:             query,
+		mutation:          mutation,
+		subscription:      subscription,
+		message:           message,
+		conversation:      conversation,
+		conversationEvent: conversationEvent,
+		user:              user,
+		friendship:        friendship,
+		friendRequest:     friendRequest,
+		reactionEvent:     reactionEvent,
+		readReceipt:       readReceipt,
+		typingEvent:       typingEvent,
+		presence:          presence,
+		attachment:        attachment,
 	}
 }
 
@@ -44,5 +68,31 @@ func (r *Resolver) Message() generated.MessageResolver { return r.message }
 // Conversation returns generated.ConversationResolver implementation.
 func (r *Resolver) Conversation() generated.ConversationResolver { return r.conversation }
 
+// ConversationEvent returns generated.ConversationEventResolver implementation.
+func (r *Resolver) ConversationEvent() generated.ConversationEventResolver {
+	return r.conversationEvent
+}
+
 // Participant returns generated.ParticipantResolver implementation.
 func (r *Resolver) User() generated.UserResolver { return r.user }
+
+// Friendship returns generated.FriendshipResolver implementation.
+func (r *Resolver) Friendship() generated.FriendshipResolver { return r.friendship }
+
+// FriendRequest returns generated.FriendRequestResolver implementation.
+func (r *Resolver) FriendRequest() generated.FriendRequestResolver { return r.friendRequest }
+
+// ReactionEvent returns generated.ReactionEventResolver implementation.
+func (r *Resolver) ReactionEvent() generated.ReactionEventResolver { return r.reactionEvent }
+
+// ReadReceipt returns generated.ReadReceiptResolver implementation.
+func (r *Resolver) ReadReceipt() generated.ReadReceiptResolver { return r.readReceipt }
+
+// TypingEvent returns generated.TypingEventResolver implementation.
+func (r *Resolver) TypingEvent() generated.TypingEventResolver { return r.typingEvent }
+
+// Presence returns generated.PresenceResolver implementation.
+func (r *Resolver) Presence() generated.PresenceResolver { return r.presence }
+
+// Attachment returns generated.AttachmentResolver implementation.
+func (r *Resolver) Attachment() generated.AttachmentResolver { return r.attachment }
@@ -9,22 +9,25 @@ import (
 )
 
 type SubscriptionResolver struct {
-	messageUsecase usecase.MessageUsecase
-	userUsecase    usecase.UserUsecase
+	messageUsecase  usecase.MessageUsecase
+	userUsecase     usecase.UserUsecase
+	presenceUsecase usecase.PresenceUsecase
 }
 
 func NewSubscriptionResolver(
 	messageUsecase usecase.MessageUsecase,
 	userUsecase usecase.UserUsecase,
+	presenceUsecase usecase.PresenceUsecase,
 ) *SubscriptionResolver {
 	return &SubscriptionResolver{
-		messageUsecase: messageUsecase,
-		userUsecase:    userUsecase,
+		messageUsecase:  messageUsecase,
+		userUsecase:     userUsecase,
+		presenceUsecase: presenceUsecase,
 	}
 }
 
-func (r *SubscriptionResolver) MessagePosted(ctx context.Context) (<-chan *entity.Message, error) {
-	messages, err := r.messageUsecase.MessagePosted(ctx)
+func (r *SubscriptionResolver) MessagePosted(ctx context.Context, conversationID *entity.ID) (<-chan *entity.Message, error) {
+	messages, err := r.messageUsecase.MessagePosted(ctx, conversationID)
 	if err != nil {
 		return nil, fmt.Errorf("message posted: %w", err)
 	}
@@ -32,6 +35,60 @@ func (r *SubscriptionResolver) MessagePosted(ctx context.Context) (<-chan *entit
 	return messages, nil
 }
 
+func (r *SubscriptionResolver) MessageUpdated(ctx context.Context, conversationID *entity.ID) (<-chan *entity.Message, error) {
+	messages, err := r.messageUsecase.MessageUpdated(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("message updated: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *SubscriptionResolver) MessageDeleted(ctx context.Context, conversationID *entity.ID) (<-chan *entity.Message, error) {
+	messages, err := r.messageUsecase.MessageDeleted(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("message deleted: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *SubscriptionResolver) ReactionChanged(ctx context.Context, conversationID *entity.ID) (<-chan *entity.ReactionEvent, error) {
+	events, err := r.messageUsecase.ReactionChanged(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("reaction changed: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *SubscriptionResolver) TypingChanged(ctx context.Context, conversationID entity.ID) (<-chan *entity.TypingEvent, error) {
+	events, err := r.messageUsecase.TypingChanged(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("typing changed: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *SubscriptionResolver) ConversationUpdated(ctx context.Context) (<-chan *entity.ConversationEvent, error) {
+	events, err := r.messageUsecase.ConversationUpdated(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("conversation updated: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *SubscriptionResolver) PresenceChanged(ctx context.Context) (<-chan *entity.Presence, error) {
+	presences, err := r.presenceUsecase.PresenceChanged(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("presence changed: %w", err)
+	}
+
+	return presences, nil
+}
+
 func (r *SubscriptionResolver) UserJoined(ctx context.Context) (<-chan *entity.User, error) {
 	users, err := r.userUsecase.UserJoined(ctx)
 	if err != nil {
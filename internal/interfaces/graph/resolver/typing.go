@@ -0,0 +1,31 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+	"github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
+)
+
+type TypingEventResolver struct {
+	loaders loader.Provider
+}
+
+func NewTypingEventResolver(loaders loader.Provider) *TypingEventResolver {
+	return &TypingEventResolver{
+		loaders: loaders,
+	}
+}
+
+func (r *TypingEventResolver) User(
+	ctx context.Context,
+	obj *entity.TypingEvent,
+) (*entity.User, error) {
+	user, err := r.loaders.UserLoader(ctx).LoadUser(ctx, obj.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type FriendshipUsecase interface {
+	SendFriendRequest(ctx context.Context, userID entity.ID,
+		friendID entity.ID) (*entity.Friendship, error)
+	AcceptFriendRequest(ctx context.Context, userID entity.ID,
+		senderID entity.ID) (*entity.Friendship, error)
+	DeclineFriendRequest(ctx context.Context, userID entity.ID,
+		senderID entity.ID) error
+	RemoveFriend(ctx context.Context, userID entity.ID, friendID entity.ID) error
+	BlockUser(ctx context.Context, userID entity.ID,
+		blockedID entity.ID) (*entity.Friendship, error)
+	PendingFriendRequests(ctx context.Context,
+		userID entity.ID) ([]*entity.FriendRequest, error)
+}
@@ -13,6 +13,15 @@ type MessageUsecase interface {
 		msgType entity.MessageType,
 		senderID entity.ID,
 		text string,
+		replyToID *entity.ID,
+	) (*entity.Message, error)
+	PostAttachment(
+		ctx context.Context,
+		conversationID entity.ID,
+		senderID entity.ID,
+		upload entity.FileUpload,
+		text string,
+		replyToID *entity.ID,
 	) (*entity.Message, error)
 	CreateNewConversation(
 		ctx context.Context,
@@ -22,5 +31,99 @@ type MessageUsecase interface {
 		recipentIDs []entity.ID,
 		text *string,
 	) (*entity.Conversation, error)
-	MessagePosted(ctx context.Context) (<-chan *entity.Message, error)
+	MessagePosted(ctx context.Context,
+		conversationID *entity.ID) (<-chan *entity.Message, error)
+	EditMessage(
+		ctx context.Context,
+		userID entity.ID,
+		messageID entity.ID,
+		text string,
+	) (*entity.Message, error)
+	MessageUpdated(ctx context.Context,
+		conversationID *entity.ID) (<-chan *entity.Message, error)
+	DeleteMessage(
+		ctx context.Context,
+		userID entity.ID,
+		messageID entity.ID,
+	) (*entity.Message, error)
+	MessageDeleted(ctx context.Context,
+		conversationID *entity.ID) (<-chan *entity.Message, error)
+	AddReaction(
+		ctx context.Context,
+		userID entity.ID,
+		messageID entity.ID,
+		emoji string,
+	) (*entity.Message, error)
+	RemoveReaction(
+		ctx context.Context,
+		userID entity.ID,
+		messageID entity.ID,
+		emoji string,
+	) (*entity.Message, error)
+	MarkConversationRead(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+		messageID entity.ID,
+	) (*entity.Conversation, error)
+	StartTyping(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+	) (*entity.TypingEvent, error)
+	StopTyping(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+	) (*entity.TypingEvent, error)
+	TypingChanged(ctx context.Context,
+		conversationID entity.ID) (<-chan *entity.TypingEvent, error)
+	ReactionChanged(ctx context.Context,
+		conversationID *entity.ID) (<-chan *entity.ReactionEvent, error)
+	RenameConversation(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+		title string,
+	) (*entity.Conversation, error)
+	AddParticipants(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+		participantIDs []entity.ID,
+	) (*entity.Conversation, error)
+	RemoveParticipant(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+		participantID entity.ID,
+	) (*entity.Conversation, error)
+	PromoteParticipant(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+		participantID entity.ID,
+	) (*entity.Conversation, error)
+	DemoteParticipant(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+		participantID entity.ID,
+	) (*entity.Conversation, error)
+	LeaveConversation(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+	) error
+	DeleteConversation(
+		ctx context.Context,
+		userID entity.ID,
+		conversationID entity.ID,
+	) error
+	ConversationUpdated(ctx context.Context) (<-chan *entity.ConversationEvent, error)
+	SearchMessages(
+		ctx context.Context,
+		userID entity.ID,
+		query entity.MessageSearchQuery,
+	) (*entity.MessageSearchConnection, error)
 }
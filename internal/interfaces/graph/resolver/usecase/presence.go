@@ -0,0 +1,13 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/samthehai/chat/internal/domain/entity"
+)
+
+type PresenceUsecase interface {
+	SetPresence(ctx context.Context,
+		status entity.PresenceStatus) (*entity.Presence, error)
+	PresenceChanged(ctx context.Context) (<-chan *entity.Presence, error)
+}
@@ -8,9 +8,9 @@ import (
 
 type UserUsecase interface {
 	GetUserFromContext(ctx context.Context) (*entity.User, error)
-	Friends(ctx context.Context, first int, after entity.ID,
-		sortBy entity.FriendsSortByType,
-		sortOrder entity.SortOrderType) (*entity.FriendsConnection, error)
+	GetAuthTokenFromContext(ctx context.Context) (*entity.AuthToken, error)
+	Friends(ctx context.Context,
+		input entity.ListQueryInput) (*entity.FriendsConnection, error)
 	UserJoined(ctx context.Context) (<-chan *entity.User, error)
 	Login(ctx context.Context) (*entity.User, error)
 	Me(ctx context.Context) (*entity.User, error)
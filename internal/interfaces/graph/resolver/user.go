@@ -3,40 +3,41 @@ package resolver
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samthehai/chat/internal/domain/entity"
 	"github.com/samthehai/chat/internal/interfaces/graph/resolver/loader"
 )
 
 type UserResolver struct {
-	userLoader         loader.UserLoader
-	conversationLoader loader.ConversationLoader
+	loaders loader.Provider
 }
 
-func NewUserResolver(userLoader loader.UserLoader,
-	conversationLoader loader.ConversationLoader) *UserResolver {
+func NewUserResolver(loaders loader.Provider) *UserResolver {
 	return &UserResolver{
-		userLoader:         userLoader,
-		conversationLoader: conversationLoader,
+		loaders: loaders,
 	}
 }
 
 func (r *UserResolver) Friends(
 	ctx context.Context,
 	obj *entity.User,
-	first int,
-	after entity.ID,
+	first *int,
+	after *entity.Cursor,
+	last *int,
+	before *entity.Cursor,
 	sortBy entity.FriendsSortByType,
 	sortOrder entity.SortOrderType,
 ) (*entity.FriendsConnection, error) {
-	idsCon, err := r.userLoader.LoadFriendIDs(ctx, entity.RelayQueryInput{
-		KeyID: obj.ID,
-		ListQueryInput: entity.ListQueryInput{
-			First:     first,
-			After:     after,
-			SortBy:    string(sortBy),
-			SortOrder: sortOrder,
-		},
+	input, err := entity.NewListQueryInput(first, after, last, before,
+		string(sortBy), sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("new list query input: %w", err)
+	}
+
+	idsCon, err := r.loaders.UserLoader(ctx).LoadFriendIDs(ctx, entity.RelayQueryInput{
+		KeyID:          obj.ID,
+		ListQueryInput: input,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("load friend ids: %w", err)
@@ -50,13 +51,13 @@ func (r *UserResolver) Friends(
 	friendsEdges := make([]*entity.FriendsEdge, 0, len(idsCon.Edges))
 
 	for _, edge := range idsCon.Edges {
-		user, err := r.userLoader.LoadUser(ctx, edge.Node)
+		user, err := r.loaders.UserLoader(ctx).LoadUser(ctx, edge.Node)
 		if err != nil {
 			return nil, fmt.Errorf("load user: %w", err)
 		}
 
 		friendsEdges = append(friendsEdges, &entity.FriendsEdge{
-			Cursor: user.ID,
+			Cursor: edge.Cursor,
 			Node:   user,
 		})
 	}
@@ -71,20 +72,23 @@ func (r *UserResolver) Friends(
 func (r *UserResolver) Conversations(
 	ctx context.Context,
 	obj *entity.User,
-	first int,
-	after entity.ID,
+	first *int,
+	after *entity.Cursor,
+	last *int,
+	before *entity.Cursor,
 	sortBy entity.ConversationsSortByType,
 	sortOrder entity.SortOrderType,
 ) (*entity.ConversationsConnection, error) {
-	idsCon, err := r.conversationLoader.LoadConversationIDsFromUser(ctx,
+	input, err := entity.NewListQueryInput(first, after, last, before,
+		string(sortBy), sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("new list query input: %w", err)
+	}
+
+	idsCon, err := r.loaders.ConversationLoader(ctx).LoadConversationIDsFromUser(ctx,
 		entity.RelayQueryInput{
-			KeyID: obj.ID,
-			ListQueryInput: entity.ListQueryInput{
-				First:     first,
-				After:     after,
-				SortBy:    string(sortBy),
-				SortOrder: sortOrder,
-			},
+			KeyID:          obj.ID,
+			ListQueryInput: input,
 		})
 	if err != nil {
 		return nil, fmt.Errorf("load conversation ids: %w", err)
@@ -98,13 +102,13 @@ func (r *UserResolver) Conversations(
 	conversationsEdges := make([]*entity.ConversationsEdge, 0, len(idsCon.Edges))
 
 	for _, edge := range idsCon.Edges {
-		conversation, err := r.conversationLoader.LoadConversation(ctx, edge.Node)
+		conversation, err := r.loaders.ConversationLoader(ctx).LoadConversation(ctx, edge.Node)
 		if err != nil {
 			return nil, fmt.Errorf("load conversation: %w", err)
 		}
 
 		conversationsEdges = append(conversationsEdges, &entity.ConversationsEdge{
-			Cursor: conversation.ID,
+			Cursor: edge.Cursor,
 			Node:   conversation,
 		})
 	}
@@ -115,3 +119,27 @@ func (r *UserResolver) Conversations(
 		TotalCount: idsCon.TotalCount,
 	}, nil
 }
+
+func (r *UserResolver) Presence(
+	ctx context.Context,
+	obj *entity.User,
+) (entity.PresenceStatus, error) {
+	presence, err := r.loaders.PresenceLoader(ctx).LoadPresence(ctx, obj.ID)
+	if err != nil {
+		return "", fmt.Errorf("load presence: %w", err)
+	}
+
+	return presence.Status, nil
+}
+
+func (r *UserResolver) LastSeenAt(
+	ctx context.Context,
+	obj *entity.User,
+) (*time.Time, error) {
+	presence, err := r.loaders.PresenceLoader(ctx).LoadPresence(ctx, obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load presence: %w", err)
+	}
+
+	return presence.LastSeenAt, nil
+}